@@ -20,6 +20,7 @@ type Config struct {
 	AuthServicePort    string
 	UserServicePort    string
 	ChatServicePort    string
+	ImportMaxBytes     int // Largest chat export accepted, the same as in the chat service
 }
 
 type RedisConfig struct {
@@ -75,6 +76,7 @@ func LoadConfig() (Config, error) {
 		AuthServicePort:    viper.GetString("AUTH_SERVICE_PORT"),
 		UserServicePort:    viper.GetString("USER_SERVICE_PORT"),
 		ChatServicePort:    viper.GetString("USER_SERVICE_PORT"),
+		ImportMaxBytes:     viper.GetInt("IMPORT_MAX_BYTES"),
 	}
 	return config, nil
 }
@@ -107,4 +109,5 @@ func setDefaultValues() {
 	viper.SetDefault("APP_DEBUG", true)
 	viper.SetDefault("SERVER_PORT", "8181")
 	viper.SetDefault("SHUTDOWN_TIMEOUT", 5)
+	viper.SetDefault("IMPORT_MAX_BYTES", 16*1024*1024)
 }
@@ -141,8 +141,8 @@ func (c *Cacher) Publish(channelName string, message string) error {
 	return c.rdb.Publish(context.Background(), channelName, message).Err()
 }
 
-func (c *Cacher) Subcribe(channelName string) *redis.PubSub {
-	subscriber := c.rdb.Subscribe(context.Background(), channelName)
+func (c *Cacher) Subcribe(channelNames ...string) *redis.PubSub {
+	subscriber := c.rdb.Subscribe(context.Background(), channelNames...)
 	return subscriber
 }
 
@@ -3,12 +3,18 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"project/api-gateway/database"
 	"project/api-gateway/helper"
 	"project/api-gateway/model"
 	"project/api-gateway/service"
+	pbChat "project/chat-service/proto"
+	"project/chat-service/realtime"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -16,9 +22,10 @@ import (
 )
 
 type ChatController struct {
-	service service.Service
-	logger  *zap.Logger
-	rdb     database.Cacher
+	service        service.Service
+	logger         *zap.Logger
+	rdb            database.Cacher
+	importMaxBytes int64 // Largest export file ImportChat accepts
 }
 
 var upgrader = websocket.Upgrader{
@@ -29,8 +36,8 @@ var upgrader = websocket.Upgrader{
 }
 var broadcast = make(chan string)
 
-func NewChatController(service service.Service, logger *zap.Logger, rdb database.Cacher) *ChatController {
-	return &ChatController{service, logger, rdb}
+func NewChatController(service service.Service, logger *zap.Logger, rdb database.Cacher, importMaxBytes int) *ChatController {
+	return &ChatController{service, logger, rdb, int64(importMaxBytes)}
 }
 
 func (ctrl *ChatController) Websocket(c *gin.Context) {
@@ -38,6 +45,23 @@ func (ctrl *ChatController) Websocket(c *gin.Context) {
 	username := c.MustGet("email").(string)
 	if username == "" {
 		BadResponse(c, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	roomId := c.Param("id")
+	uintRoomId, err := helper.Uint(roomId)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// removed users must not reconnect to the room
+	joined, err := ctrl.isParticipant(uintRoomId, username)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !joined {
+		BadResponse(c, "not a participant of this room", http.StatusForbidden)
+		return
 	}
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -45,15 +69,10 @@ func (ctrl *ChatController) Websocket(c *gin.Context) {
 		return
 	}
 	defer conn.Close()
-	roomId := c.Param("id")
-	uintRoomId, err := helper.Uint(roomId)
 	message.Sender = username
 	message.RoomId = uintRoomId
-	if err != nil {
-		log.Println("ERROR PARSING UINT")
-		return
-	}
-	pubsub := ctrl.rdb.Subcribe("room:" + roomId)
+	// the user channel carries events from the user's other rooms, like mentions
+	pubsub := ctrl.rdb.Subcribe(realtime.RoomChannel(uintRoomId), realtime.UserChannel(username))
 	defer pubsub.Close()
 	go func() {
 		for {
@@ -64,11 +83,22 @@ func (ctrl *ChatController) Websocket(c *gin.Context) {
 			}
 			// log.Println(payload.Payload, "++++++")
 			// log.Printf("%+v<<<<<<--------\n", message)
+			if !eventVisibleTo(payload.Payload, username) {
+				continue
+			}
 			err = conn.WriteMessage(websocket.TextMessage, []byte(payload.Payload))
 			if err != nil {
 				log.Println("Write error:", err)
 				break
 			}
+			if removedFromRoom(payload.Payload, username) {
+				// closing the connection also ends the read loop below
+				closing := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "removed from room")
+				conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(time.Second))
+				conn.Close()
+				return
+			}
+			ctrl.acknowledgeDelivery(uintRoomId, username, payload.Payload)
 		}
 	}()
 	for {
@@ -77,39 +107,181 @@ func (ctrl *ChatController) Websocket(c *gin.Context) {
 			log.Println("Read error:", err)
 			break
 		}
-		chat := string(msg)
+		var ack model.SocketAck
+		if err = json.Unmarshal(msg, &ack); err == nil && ack.Type == model.SocketAckRead {
+			ctrl.acknowledgeRead(uintRoomId, username, ack.MessageId)
+			continue
+		}
+		message = model.Message{Sender: username, RoomId: uintRoomId}
 		err = json.Unmarshal(msg, &message)
 		if err != nil {
 			log.Println(err)
 			return
 		}
+		message.Sender = username
+		message.RoomId = uintRoomId
 		err = ctrl.service.Chat.SaveMessage(&message)
 		if err != nil {
 			log.Println(err, "DARI SAVE MESSAGE HANDLER")
 			return
 		}
+		// commands answer through their own events, the message itself is not stored
+		if message.Command != "" {
+			continue
+		}
 
-		ctrl.rdb.Publish("room:"+roomId, chat)
+		// publish the saved message so recipients get its id to acknowledge
+		chat, err := json.Marshal(message)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		ctrl.rdb.Publish(realtime.RoomChannel(uintRoomId), string(chat))
+		ctrl.notifyMentions(message)
 	}
 }
-func (ctrl *ChatController) GetRoomMessages(c *gin.Context) {
-	query := c.Query("page")
-	var page uint
-	var err error
-	if query != "" {
-		page, err = helper.Uint(query)
+
+// notifyMentions pushes a mention event to every user mentioned in a message,
+// whichever room their websockets are open on
+func (ctrl *ChatController) notifyMentions(message model.Message) {
+	var mentioned []string
+	for _, e := range message.Entities {
+		if e.Type == model.EntityMention {
+			mentioned = append(mentioned, e.UserEmail)
+		}
+	}
+	for _, recipient := range realtime.MentionRecipients(message.Sender, mentioned) {
+		payload, err := json.Marshal(realtime.Event{Type: realtime.EventMention, RoomId: message.RoomId, Recipient: recipient, Data: message})
 		if err != nil {
-			BadResponse(c, err.Error(), http.StatusBadRequest)
-			return
+			ctrl.logger.Error("failed to encode mention event", zap.Error(err))
+			continue
+		}
+		if err := ctrl.rdb.Publish(realtime.UserChannel(recipient), string(payload)); err != nil {
+			ctrl.logger.Error("failed to publish mention event", zap.String("email", recipient), zap.Error(err))
+		}
+	}
+}
+
+// isParticipant reports whether email is in the room
+func (ctrl *ChatController) isParticipant(roomId uint, email string) (bool, error) {
+	res, err := ctrl.service.Chat.GetRoomParticipants(roomId)
+	if err != nil {
+		return false, err
+	}
+	for _, user := range res.Users {
+		if user.Username == email {
+			return true, nil
 		}
 	}
+	return false, nil
+}
+
+// acknowledgeDelivery marks a chat message written to the websocket of email as
+// delivered and tells its sender. Events and the user's own messages are skipped.
+func (ctrl *ChatController) acknowledgeDelivery(roomId uint, email, payload string) {
+	var message model.Message
+	if err := json.Unmarshal([]byte(payload), &message); err != nil || message.Id == 0 || message.Sender == email {
+		return
+	}
+	res, err := ctrl.service.Chat.MarkDelivered(roomId, email, message.Id)
+	if err != nil {
+		ctrl.logger.Error("failed to mark message as delivered", zap.Uint("messageId", message.Id), zap.Error(err))
+		return
+	}
+	if res.Marked {
+		ctrl.publishEventTo(roomId, res.SenderEmail, realtime.EventMessageDelivered, res)
+	}
+}
+
+// acknowledgeRead handles a read ack sent by a client over its websocket
+func (ctrl *ChatController) acknowledgeRead(roomId uint, email string, messageId uint) {
+	res, err := ctrl.service.Chat.MarkRead(roomId, email, messageId)
+	if err != nil {
+		ctrl.logger.Error("failed to mark messages as read", zap.Uint("messageId", messageId), zap.Error(err))
+		return
+	}
+	if res.Marked > 0 {
+		ctrl.publishEvent(roomId, realtime.EventMessagesRead, res)
+	}
+}
+
+// CreateRoom creates a room owned by the caller
+func (ctrl *ChatController) CreateRoom(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	var input model.CreateRoom
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.CreateRoom(email, input)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Create Room Success", http.StatusOK, res)
+}
+
+// RenameRoom renames the room. The chat service posts the system message
+// announcing it to the room itself.
+func (ctrl *ChatController) RenameRoom(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.RenameRoom
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.RenameRoom(roomId, email, input.RoomName)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Rename Room Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ListRooms(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	res, err := ctrl.service.Chat.ListUserRooms(email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Get Rooms Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ListMentions(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	var query model.PageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ListMentions(email, query)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Get Mentions Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) GetRoomMessages(c *gin.Context) {
+	var query model.HistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
 	param := c.Param("id")
 	roomId, err := helper.Uint(param)
 	if err != nil {
 		BadResponse(c, err.Error(), http.StatusBadRequest)
 		return
 	}
-	res, err := ctrl.service.Chat.GetRoomMessages(roomId, page)
+	email := c.MustGet("email").(string)
+	res, err := ctrl.service.Chat.GetRoomMessages(roomId, email, query)
 	if err != nil {
 		BadResponse(c, err.Error(), http.StatusBadRequest)
 		return
@@ -131,6 +303,7 @@ func (ctrl *ChatController) GetAllParticipants(c *gin.Context) {
 	GoodResponseWithData(c, "Get Participants Success", http.StatusOK, res)
 }
 func (ctrl *ChatController) AddParticipants(c *gin.Context) {
+	email := c.MustGet("email").(string)
 	param := c.Param("id")
 	roomId, err := helper.Uint(param)
 	if err != nil {
@@ -142,10 +315,974 @@ func (ctrl *ChatController) AddParticipants(c *gin.Context) {
 		BadResponse(c, err.Error(), http.StatusBadRequest)
 		return
 	}
-	res, err := ctrl.service.Chat.AddRoomParticipant(uint64(roomId), participant.Email)
+	res, err := ctrl.service.Chat.AddRoomParticipant(uint64(roomId), email, participant.Email)
 	if err != nil {
 		BadResponse(c, err.Error(), http.StatusBadRequest)
 		return
 	}
 	GoodResponseWithData(c, "Get Participants Success", http.StatusOK, res)
 }
+
+// SetParticipantRole makes a participant an admin or a member of the room,
+// which only the room owner may do
+func (ctrl *ChatController) SetParticipantRole(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.ParticipantRole
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.SetParticipantRole(roomId, email, c.Param("email"), input.Role)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Set Participant Role Success", http.StatusOK, res)
+}
+
+// RemoveParticipant removes a user from the room. The chat service announces it
+// and closes the user's websockets on the room.
+func (ctrl *ChatController) RemoveParticipant(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.RemoveRoomParticipant(roomId, email, c.Param("email"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Remove Participant Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) LeaveRoom(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.LeaveRoom(roomId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Leave Room Success", http.StatusOK, res)
+}
+
+// TransferOwnership hands the room to another participant, the caller stays on
+// as an admin
+func (ctrl *ChatController) TransferOwnership(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.TransferOwnership
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.TransferOwnership(roomId, email, input.Email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Transfer Ownership Success", http.StatusOK, res)
+}
+
+// CreateInvite creates an invite link to the room. The token is only returned
+// once, the link is /invites/:token/accept.
+func (ctrl *ChatController) CreateInvite(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// every field is optional, so is the body
+	var input model.CreateInvite
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.CreateInvite(roomId, email, input)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Create Invite Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ListInvites(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ListInvites(roomId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Get Invites Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) RevokeInvite(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	inviteId, err := helper.Uint(c.Param("inviteId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.RevokeInvite(roomId, inviteId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Revoke Invite Success", http.StatusOK, res)
+}
+
+// AcceptInvite joins the caller to the room of an invite link
+func (ctrl *ChatController) AcceptInvite(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	res, err := ctrl.service.Chat.AcceptInvite(c.Param("token"), email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Accept Invite Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) EditMessage(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	messageId, err := helper.Uint(c.Param("msgId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.MessageContent
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.EditMessage(roomId, messageId, email, input.Content)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctrl.publishEvent(roomId, realtime.EventMessageEdited, res)
+	GoodResponseWithData(c, "Edit Message Success", http.StatusOK, res)
+}
+
+// DeleteMessage removes a message for everyone with ?scope=everyone,
+// otherwise only for the caller
+func (ctrl *ChatController) DeleteMessage(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	messageId, err := helper.Uint(c.Param("msgId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	forEveryone := c.Query("scope") == "everyone"
+	res, err := ctrl.service.Chat.DeleteMessage(roomId, messageId, email, forEveryone)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if forEveryone {
+		ctrl.publishEvent(roomId, realtime.EventMessageDeleted, res)
+	} else {
+		ctrl.publishEventTo(roomId, email, realtime.EventMessageDeleted, res)
+	}
+	GoodResponseWithData(c, "Delete Message Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) MarkRead(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.MarkRead
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.MarkRead(roomId, email, input.UpToMessageId)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if res.Marked > 0 {
+		ctrl.publishEvent(roomId, realtime.EventMessagesRead, res)
+	}
+	GoodResponseWithData(c, "Mark Read Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) AddReaction(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	messageId, err := helper.Uint(c.Param("msgId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.Reaction
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.AddReaction(roomId, messageId, email, input.Emoji)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctrl.publishEvent(roomId, realtime.EventReactionAdded, res)
+	GoodResponseWithData(c, "Add Reaction Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) RemoveReaction(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	messageId, err := helper.Uint(c.Param("msgId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.RemoveReaction(roomId, messageId, email, c.Param("emoji"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctrl.publishEvent(roomId, realtime.EventReactionRemoved, res)
+	GoodResponseWithData(c, "Remove Reaction Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) GetThread(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	var query model.PageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	messageId, err := helper.Uint(c.Param("msgId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.GetThread(roomId, messageId, email, query)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Get Thread Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) PinMessage(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	messageId, err := helper.Uint(c.Param("msgId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.PinMessage(roomId, messageId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctrl.publishEvent(roomId, realtime.EventMessagePinned, res)
+	GoodResponseWithData(c, "Pin Message Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) UnpinMessage(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	messageId, err := helper.Uint(c.Param("msgId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.UnpinMessage(roomId, messageId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctrl.publishEvent(roomId, realtime.EventMessageUnpinned, res)
+	GoodResponseWithData(c, "Unpin Message Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ListPinnedMessages(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ListPinnedMessages(roomId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Get Pinned Messages Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ForwardMessage(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	messageId, err := helper.Uint(c.Param("msgId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.ForwardMessage
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ForwardMessage(roomId, messageId, email, input.RoomIds)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the copies are new messages of their rooms, publish them like sent ones
+	for _, f := range res.Forwarded {
+		message := model.Message{
+			Id:              uint(f.Message.MessageId),
+			RoomId:          uint(f.RoomId),
+			Sender:          f.Message.SenderEmail,
+			Content:         f.Message.Content,
+			AttachmentUrl:   f.Message.AttachmentUrl,
+			ForwardedFrom:   uint(f.Message.ForwardedFrom),
+			ForwardedSender: f.Message.ForwardedFromSender,
+			Type:            f.Message.Type,
+			Payload:         messagePayload(f.Message),
+			Entities:        service.ToEntities(f.Message.Entities),
+		}
+		chat, err := json.Marshal(message)
+		if err != nil {
+			ctrl.logger.Error("failed to marshal forwarded message", zap.Error(err))
+			continue
+		}
+		if err := ctrl.rdb.Publish(realtime.RoomChannel(uint(f.RoomId)), string(chat)); err != nil {
+			ctrl.logger.Error("failed to publish forwarded message", zap.Uint64("roomId", f.RoomId), zap.Error(err))
+		}
+		ctrl.notifyMentions(message)
+	}
+	GoodResponseWithData(c, "Forward Message Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) SearchMessages(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	var search model.MessageSearch
+	if err := c.ShouldBindQuery(&search); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.SearchMessages(email, search)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Search Messages Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ScheduleMessage(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.ScheduleMessage
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ScheduleMessage(roomId, email, input)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Schedule Message Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ListScheduled(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ListScheduled(roomId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Get Scheduled Messages Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) CancelScheduled(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	scheduledId, err := helper.Uint(c.Param("scheduledId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.CancelScheduled(scheduledId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Cancel Scheduled Message Success", http.StatusOK, res)
+}
+
+// SetRoomRetention changes how long new messages of the room are kept. The
+// chat service posts the system message announcing it to the room itself.
+func (ctrl *ChatController) SetRoomRetention(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.RoomRetention
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.SetRoomRetention(roomId, email, input.Retention)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Set Room Retention Success", http.StatusOK, res)
+}
+
+// CreatePoll posts a poll to the room. The chat service publishes the poll
+// message, and every later tally, to the room itself.
+func (ctrl *ChatController) CreatePoll(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.CreatePoll
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.CreatePoll(roomId, email, input)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Create Poll Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) Vote(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pollId, err := helper.Uint(c.Param("pollId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.PollVote
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.Vote(roomId, pollId, email, input.OptionIds)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Vote Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) RetractVote(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pollId, err := helper.Uint(c.Param("pollId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.RetractVote(roomId, pollId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Retract Vote Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ClosePoll(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pollId, err := helper.Uint(c.Param("pollId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ClosePoll(roomId, pollId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Close Poll Success", http.StatusOK, res)
+}
+
+// CreateBot registers a bot owned by the caller. The token in the response is
+// what the bot authenticates with against the chat service and is not shown again.
+func (ctrl *ChatController) CreateBot(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	var input model.CreateBot
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.CreateBot(input.Name, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Create Bot Success", http.StatusOK, res)
+}
+
+// InstallBot lets a bot answer its commands in the room, the caller must be a
+// room admin
+func (ctrl *ChatController) InstallBot(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.InstallBot
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.InstallBot(roomId, input.Name, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Install Bot Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) UninstallBot(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.UninstallBot(roomId, c.Param("name"), email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Uninstall Bot Success", http.StatusOK, res)
+}
+
+// CreateWebhook registers a webhook on the room. The secret its payloads are
+// signed with is only part of this response.
+func (ctrl *ChatController) CreateWebhook(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.CreateWebhook
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.CreateWebhook(roomId, email, input.URL)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Create Webhook Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ListWebhooks(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ListWebhooks(roomId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Get Webhooks Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) DeleteWebhook(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	webhookId, err := helper.Uint(c.Param("webhookId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.DeleteWebhook(roomId, webhookId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Delete Webhook Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ListWebhookDeliveries(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	var query model.PageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	webhookId, err := helper.Uint(c.Param("webhookId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ListWebhookDeliveries(roomId, webhookId, email, query)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Get Webhook Deliveries Success", http.StatusOK, res)
+}
+
+// ImportChat creates a room from a WhatsApp or Telegram export. Uploading the
+// same file again resumes an import that did not complete. The users senders
+// maps to get invite tokens in the response, they join once they accept them.
+func (ctrl *ChatController) ImportChat(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	var input model.ImportChat
+	if err := c.ShouldBind(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	senders := map[string]string{}
+	if input.Senders != "" {
+		if err := json.Unmarshal([]byte(input.Senders), &senders); err != nil {
+			BadResponse(c, "senders must map display names to emails", http.StatusBadRequest)
+			return
+		}
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if header.Size > ctrl.importMaxBytes {
+		BadResponse(c, fmt.Sprintf("export file is larger than %d bytes", ctrl.importMaxBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, ctrl.importMaxBytes))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := ctrl.service.Chat.ImportChat(email, input, senders, data)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Import Chat Success", http.StatusOK, res)
+}
+
+var exportFiles = map[string]struct{ contentType, extension string }{
+	model.ExportFormatJSONL: {"application/x-ndjson", "jsonl"},
+	model.ExportFormatHTML:  {"text/html; charset=utf-8", "html"},
+	model.ExportFormatText:  {"text/plain; charset=utf-8", "txt"},
+}
+
+// ExportRoom downloads the transcript of a room. Errors are only reported as a
+// response until the first chunk is written.
+func (ctrl *ChatController) ExportRoom(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	var query model.ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Format == "" {
+		query.Format = model.ExportFormatJSONL
+	}
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file := exportFiles[query.Format]
+	started := false
+	start := func() {
+		started = true
+		c.Header("Content-Type", file.contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="room-%d.%s"`, roomId, file.extension))
+		c.Status(http.StatusOK)
+	}
+	err = ctrl.service.Chat.ExportRoom(c.Request.Context(), roomId, email, query.Format, func(chunk []byte) error {
+		if !started {
+			start()
+		}
+		_, err := c.Writer.Write(chunk)
+		return err
+	})
+	if err != nil {
+		if !started {
+			BadResponse(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctrl.logger.Error("room export interrupted", zap.Uint("roomId", roomId), zap.Error(err))
+		return
+	}
+	if !started {
+		start()
+	}
+}
+
+// CreateIncomingWebhook returns the token of the /hooks/:token URL outside
+// services post into the room with. It is not shown again.
+func (ctrl *ChatController) CreateIncomingWebhook(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var input model.CreateIncomingWebhook
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.CreateIncomingWebhook(roomId, email, input.Name)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Create Incoming Webhook Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) ListIncomingWebhooks(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.ListIncomingWebhooks(roomId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Get Incoming Webhooks Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) RotateIncomingWebhook(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hookId, err := helper.Uint(c.Param("hookId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.RotateIncomingWebhook(roomId, hookId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Rotate Incoming Webhook Success", http.StatusOK, res)
+}
+
+func (ctrl *ChatController) RevokeIncomingWebhook(c *gin.Context) {
+	email := c.MustGet("email").(string)
+	roomId, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hookId, err := helper.Uint(c.Param("hookId"))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.RevokeIncomingWebhook(roomId, hookId, email)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Revoke Incoming Webhook Success", http.StatusOK, res)
+}
+
+// PostIncomingWebhook posts a Slack compatible message into the room the token
+// belongs to. The token is the only credential, the chat service publishes the
+// message to the room.
+func (ctrl *ChatController) PostIncomingWebhook(c *gin.Context) {
+	var input model.IncomingWebhookMessage
+	if err := c.ShouldBindJSON(&input); err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := ctrl.service.Chat.PostIncomingWebhook(c.Param("token"), input)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	GoodResponseWithData(c, "Post Incoming Webhook Success", http.StatusOK, res)
+}
+
+// messagePayload encodes the payload of a message the way websocket clients send it
+func messagePayload(m *pbChat.Message) json.RawMessage {
+	var payload any
+	switch {
+	case m.GetLocation() != nil:
+		l := m.GetLocation()
+		payload = model.Location{Latitude: l.Latitude, Longitude: l.Longitude, Name: l.Name, Address: l.Address}
+	case m.GetContact() != nil:
+		c := m.GetContact()
+		payload = model.ContactCard{Name: c.Name, Email: c.Email, Phone: c.Phone}
+	case m.GetFile() != nil:
+		f := m.GetFile()
+		payload = model.FileInfo{Url: f.Url, Name: f.Name, MimeType: f.MimeType, Size: f.Size}
+	default:
+		return nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// publishEvent fans an event out to every websocket subscribed to the room
+func (ctrl *ChatController) publishEvent(roomId uint, eventType string, data any) {
+	ctrl.publishEventTo(roomId, "", eventType, data)
+}
+
+// publishEventTo sends an event through the room channel that only the
+// recipient's websockets deliver
+func (ctrl *ChatController) publishEventTo(roomId uint, recipient, eventType string, data any) {
+	payload, err := json.Marshal(realtime.Event{Type: eventType, RoomId: roomId, Recipient: recipient, Data: data})
+	if err != nil {
+		ctrl.logger.Error("failed to encode chat event", zap.String("type", eventType), zap.Error(err))
+		return
+	}
+	if err := ctrl.rdb.Publish(realtime.RoomChannel(roomId), string(payload)); err != nil {
+		ctrl.logger.Error("failed to publish chat event", zap.String("type", eventType), zap.Error(err))
+	}
+}
+
+// removedFromRoom reports whether a room channel payload removes email from the room
+func removedFromRoom(payload, email string) bool {
+	var event realtime.Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return false
+	}
+	return event.Type == realtime.EventParticipantRemoved && event.Recipient == email
+}
+
+// eventVisibleTo reports whether a room channel payload should be written to
+// the websocket of email. Plain chat messages are visible to everyone.
+func eventVisibleTo(payload, email string) bool {
+	var event realtime.Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return true
+	}
+	return event.Recipient == "" || event.Recipient == email
+}
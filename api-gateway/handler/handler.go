@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"project/api-gateway/config"
 	"project/api-gateway/database"
 	"project/api-gateway/model"
 	"project/api-gateway/service"
@@ -16,10 +17,10 @@ type Handler struct {
 	UserHandler    UserController
 }
 
-func NewHandler(service service.Service, logger *zap.Logger, rdb database.Cacher) *Handler {
+func NewHandler(service service.Service, logger *zap.Logger, rdb database.Cacher, cfg config.Config) *Handler {
 	return &Handler{
 		AuthHandler:    *NewAuthController(service, logger, rdb),
-		ChatHandler:    *NewChatController(service, logger, rdb),
+		ChatHandler:    *NewChatController(service, logger, rdb, cfg.ImportMaxBytes),
 		ContactHandler: *NewContactController(service, logger),
 		UserHandler:    *NewUserController(service, logger),
 	}
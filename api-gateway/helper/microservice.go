@@ -1,8 +1,10 @@
 package helper
 
 import (
+	"context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"log"
 )
 
@@ -13,3 +15,12 @@ func MustConnect(clientTarget string) *grpc.ClientConn {
 	}
 	return conn
 }
+
+// CallerEmailKey is the metadata key the chat service reads the authenticated
+// user from
+const CallerEmailKey = "x-user-email"
+
+// CallerContext returns a context forwarding email as the caller of a request
+func CallerContext(ctx context.Context, email string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, CallerEmailKey, email)
+}
@@ -42,7 +42,7 @@ func NewServiceContext() (*ServiceContext, error) {
 	services := service.NewService(appConfig, logger)
 
 	// instance controller
-	Ctl := handler.NewHandler(services, logger, rdb)
+	Ctl := handler.NewHandler(services, logger, rdb, appConfig)
 
 	mw := middleware.NewMiddleware(appConfig.MicroserviceConfig, rdb)
 
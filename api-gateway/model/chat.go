@@ -1,15 +1,63 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Message struct {
-	Id            uint      `json:"id,-"`
-	RoomId        uint      `json:"roomId,-"`
-	Sender        string    `json:"sender,omitempty"`
-	Content       string    `json:"content,omitempty"`
-	AttachmentUrl string    `json:"attachmentUrl,omitempty"`
-	ReplyTo       int       `json:"replyTo,omitempty"`
-	CreatedAt     time.Time `json:"created_at,omitempty"`
+	Id            uint            `json:"id,-"`
+	RoomId        uint            `json:"roomId,-"`
+	Sender        string          `json:"sender,omitempty"`
+	Type          string          `json:"type,omitempty"` // text when empty
+	Content       string          `json:"content,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"` // Location, ContactCard or FileInfo, following Type
+	AttachmentUrl string          `json:"attachmentUrl,omitempty"`
+	ReplyTo       int             `json:"replyTo,omitempty"`
+	ForwardedFrom uint            `json:"forwardedFrom,omitempty"`
+	// ForwardedSender is the sender of the original message of a forwarded copy
+	ForwardedSender string    `json:"forwardedSender,omitempty"`
+	Entities        []Entity  `json:"entities,omitempty"`
+	CreatedAt       time.Time `json:"created_at,omitempty"`
+	Command         string    `json:"-"` // Slash command the message ran instead of being stored
+}
+
+// Message types a client can send
+const (
+	MessageTypeText     = "text"
+	MessageTypeLocation = "location"
+	MessageTypeContact  = "contact"
+	MessageTypeFile     = "file"
+)
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Name      string  `json:"name,omitempty"`
+	Address   string  `json:"address,omitempty"`
+}
+
+type ContactCard struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+type FileInfo struct {
+	Url      string `json:"url"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+const EntityMention = "mention"
+
+// Entity marks a span of a message content, such as a mention
+type Entity struct {
+	Type      string `json:"type"`
+	Offset    uint32 `json:"offset"`
+	Length    uint32 `json:"length"`
+	UserEmail string `json:"userEmail,omitempty"`
 }
 
 type RoomParticipant struct {
@@ -26,3 +74,157 @@ type Messages struct {
 type Participant struct {
 	Email string `json:"email"`
 }
+
+type CreateRoom struct {
+	RoomName string   `json:"roomName" binding:"required,max=100"`
+	Emails   []string `json:"emails" binding:"dive,email"`
+	PinLimit uint32   `json:"pinLimit"`
+}
+
+type RenameRoom struct {
+	RoomName string `json:"roomName" binding:"required,max=100"`
+}
+
+type ParticipantRole struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+type TransferOwnership struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type CreateInvite struct {
+	Role      string `json:"role" binding:"omitempty,oneof=admin member"`
+	MaxUses   uint32 `json:"maxUses"`   // 0 allows any number of uses
+	ExpiresAt string `json:"expiresAt"` // RFC 3339, never expires when empty
+}
+
+type MessageContent struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type MarkRead struct {
+	UpToMessageId uint `json:"upToMessageId" binding:"required"`
+}
+
+type ForwardMessage struct {
+	RoomIds []uint `json:"roomIds" binding:"required,min=1"`
+}
+
+// PageQuery holds the page and page size asked for in the query string
+type PageQuery struct {
+	Page  uint `form:"page"`
+	Limit uint `form:"limit"`
+}
+
+// HistoryQuery pages through room history by page number or, when a cursor
+// returned by a previous call is given, by cursor
+type HistoryQuery struct {
+	PageQuery
+	Before string `form:"before"`
+	After  string `form:"after"`
+}
+
+// MessageSearch holds the query string of a message search
+type MessageSearch struct {
+	Query  string `form:"q" binding:"required"`
+	RoomId uint   `form:"roomId"`
+	Sender string `form:"sender"`
+	From   string `form:"from"` // RFC 3339
+	To     string `form:"to"`   // RFC 3339
+	PageQuery
+}
+
+type ScheduleMessage struct {
+	Content       string `json:"content"`
+	AttachmentUrl string `json:"attachmentUrl"`
+	ReplyTo       uint   `json:"replyTo"`
+	SendAt        string `json:"sendAt" binding:"required"` // RFC 3339
+}
+
+type RoomRetention struct {
+	Retention string `json:"retention" binding:"required,oneof=off 24h 7d 90d"`
+}
+
+type CreatePoll struct {
+	Question       string   `json:"question" binding:"required"`
+	Options        []string `json:"options" binding:"required,min=2,max=10"`
+	MultipleChoice bool     `json:"multipleChoice"`
+	Anonymous      bool     `json:"anonymous"`
+}
+
+// PollVote replaces the votes of the caller on a poll
+type PollVote struct {
+	OptionIds []uint `json:"optionIds" binding:"required,min=1"`
+}
+
+type CreateBot struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// InstallBot names the bot to add to a room
+type InstallBot struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type CreateWebhook struct {
+	URL string `json:"url" binding:"required,url"`
+}
+
+// Room transcript formats
+const (
+	ExportFormatJSONL = "jsonl"
+	ExportFormatHTML  = "html"
+	ExportFormatText  = "text"
+)
+
+type ExportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=jsonl html text"` // jsonl when empty
+}
+
+// Messengers a chat can be imported from
+const (
+	ImportSourceWhatsApp = "whatsapp"
+	ImportSourceTelegram = "telegram"
+)
+
+// ImportChat is the form an export file is uploaded with, as the "file" part
+type ImportChat struct {
+	Source   string `form:"source" binding:"required,oneof=whatsapp telegram"`
+	RoomName string `form:"roomName"`
+	TimeZone string `form:"timeZone"` // IANA zone of the export timestamps, UTC when empty
+	Senders  string `form:"senders"`  // JSON object of display names to emails
+}
+
+type CreateIncomingWebhook struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// IncomingWebhookMessage is the Slack compatible body posted to an incoming webhook
+type IncomingWebhookMessage struct {
+	Text        string           `json:"text"`
+	Username    string           `json:"username"`
+	Attachments []HookAttachment `json:"attachments"`
+}
+
+type HookAttachment struct {
+	Fallback  string `json:"fallback"`
+	Color     string `json:"color"`
+	Pretext   string `json:"pretext"`
+	Title     string `json:"title"`
+	TitleLink string `json:"title_link"`
+	Text      string `json:"text"`
+	ImageUrl  string `json:"image_url"`
+}
+
+type Reaction struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+// SocketAck is sent by a client over its websocket to acknowledge messages
+type SocketAck struct {
+	Type      string `json:"type"`
+	MessageId uint   `json:"messageId"`
+}
+
+const SocketAckRead = "read"
@@ -25,10 +25,12 @@ func NewRoutes(ctx infra.ServiceContext) {
 	r.POST("/register", ctx.Ctl.AuthHandler.Register)
 	r.POST("/login", ctx.Ctl.AuthHandler.Login)
 	r.PUT("/otp/:id", ctx.Ctl.AuthHandler.ValidateOtp)
+	r.POST("/hooks/:token", ctx.Ctl.ChatHandler.PostIncomingWebhook)
 
 	r.Use(ctx.Middleware.Auth())
 	r.GET("/users", ctx.Ctl.UserHandler.GetAllUsers)
 	r.PUT("/profile", ctx.Ctl.UserHandler.UpdateProfile)
+	r.POST("/invites/:token/accept", ctx.Ctl.ChatHandler.AcceptInvite)
 
 	contactRoutes := r.Group("/user/contacts")
 	{
@@ -38,10 +40,57 @@ func NewRoutes(ctx infra.ServiceContext) {
 
 	chatRoutes := r.Group("/user/chats")
 	{
+		chatRoutes.GET("", ctx.Ctl.ChatHandler.ListRooms)
+		chatRoutes.POST("", ctx.Ctl.ChatHandler.CreateRoom)
+		chatRoutes.GET("/search", ctx.Ctl.ChatHandler.SearchMessages)
+		chatRoutes.GET("/mentions", ctx.Ctl.ChatHandler.ListMentions)
+		chatRoutes.POST("/import", ctx.Ctl.ChatHandler.ImportChat)
 		chatRoutes.GET("/:id/ws", ctx.Ctl.ChatHandler.Websocket)
+		chatRoutes.PUT("/:id", ctx.Ctl.ChatHandler.RenameRoom)
 		chatRoutes.GET("/:id/messages", ctx.Ctl.ChatHandler.GetRoomMessages)
+		chatRoutes.PUT("/:id/messages/:msgId", ctx.Ctl.ChatHandler.EditMessage)
+		chatRoutes.DELETE("/:id/messages/:msgId", ctx.Ctl.ChatHandler.DeleteMessage)
+		chatRoutes.GET("/:id/messages/:msgId/thread", ctx.Ctl.ChatHandler.GetThread)
+		chatRoutes.POST("/:id/messages/:msgId/forward", ctx.Ctl.ChatHandler.ForwardMessage)
+		chatRoutes.POST("/:id/messages/:msgId/reactions", ctx.Ctl.ChatHandler.AddReaction)
+		chatRoutes.DELETE("/:id/messages/:msgId/reactions/:emoji", ctx.Ctl.ChatHandler.RemoveReaction)
+		chatRoutes.POST("/:id/messages/:msgId/pin", ctx.Ctl.ChatHandler.PinMessage)
+		chatRoutes.DELETE("/:id/messages/:msgId/pin", ctx.Ctl.ChatHandler.UnpinMessage)
+		chatRoutes.GET("/:id/pins", ctx.Ctl.ChatHandler.ListPinnedMessages)
+		chatRoutes.GET("/:id/export", ctx.Ctl.ChatHandler.ExportRoom)
+		chatRoutes.POST("/:id/read", ctx.Ctl.ChatHandler.MarkRead)
+		chatRoutes.PUT("/:id/retention", ctx.Ctl.ChatHandler.SetRoomRetention)
+		chatRoutes.POST("/:id/scheduled", ctx.Ctl.ChatHandler.ScheduleMessage)
+		chatRoutes.GET("/:id/scheduled", ctx.Ctl.ChatHandler.ListScheduled)
+		chatRoutes.DELETE("/:id/scheduled/:scheduledId", ctx.Ctl.ChatHandler.CancelScheduled)
+		chatRoutes.POST("/:id/polls", ctx.Ctl.ChatHandler.CreatePoll)
+		chatRoutes.PUT("/:id/polls/:pollId/votes", ctx.Ctl.ChatHandler.Vote)
+		chatRoutes.DELETE("/:id/polls/:pollId/votes", ctx.Ctl.ChatHandler.RetractVote)
+		chatRoutes.POST("/:id/polls/:pollId/close", ctx.Ctl.ChatHandler.ClosePoll)
+		chatRoutes.POST("/:id/webhooks", ctx.Ctl.ChatHandler.CreateWebhook)
+		chatRoutes.GET("/:id/webhooks", ctx.Ctl.ChatHandler.ListWebhooks)
+		chatRoutes.DELETE("/:id/webhooks/:webhookId", ctx.Ctl.ChatHandler.DeleteWebhook)
+		chatRoutes.GET("/:id/webhooks/:webhookId/deliveries", ctx.Ctl.ChatHandler.ListWebhookDeliveries)
+		chatRoutes.POST("/:id/hooks", ctx.Ctl.ChatHandler.CreateIncomingWebhook)
+		chatRoutes.GET("/:id/hooks", ctx.Ctl.ChatHandler.ListIncomingWebhooks)
+		chatRoutes.POST("/:id/hooks/:hookId/rotate", ctx.Ctl.ChatHandler.RotateIncomingWebhook)
+		chatRoutes.DELETE("/:id/hooks/:hookId", ctx.Ctl.ChatHandler.RevokeIncomingWebhook)
 		chatRoutes.GET("/:id/participants", ctx.Ctl.ChatHandler.GetAllParticipants)
 		chatRoutes.POST("/:id/participants", ctx.Ctl.ChatHandler.AddParticipants)
+		chatRoutes.DELETE("/:id/participants/:email", ctx.Ctl.ChatHandler.RemoveParticipant)
+		chatRoutes.PUT("/:id/participants/:email/role", ctx.Ctl.ChatHandler.SetParticipantRole)
+		chatRoutes.POST("/:id/leave", ctx.Ctl.ChatHandler.LeaveRoom)
+		chatRoutes.POST("/:id/invites", ctx.Ctl.ChatHandler.CreateInvite)
+		chatRoutes.GET("/:id/invites", ctx.Ctl.ChatHandler.ListInvites)
+		chatRoutes.DELETE("/:id/invites/:inviteId", ctx.Ctl.ChatHandler.RevokeInvite)
+		chatRoutes.PUT("/:id/owner", ctx.Ctl.ChatHandler.TransferOwnership)
+		chatRoutes.POST("/:id/bots", ctx.Ctl.ChatHandler.InstallBot)
+		chatRoutes.DELETE("/:id/bots/:name", ctx.Ctl.ChatHandler.UninstallBot)
+	}
+
+	botRoutes := r.Group("/user/bots")
+	{
+		botRoutes.POST("", ctx.Ctl.ChatHandler.CreateBot)
 	}
 
 	gracefulShutdown(ctx, r.Handler())
@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"project/api-gateway/helper"
 	"project/api-gateway/model"
 	pbChat "project/chat-service/proto"
@@ -12,9 +16,54 @@ import (
 type ChatService interface {
 	SaveMessage(msg *model.Message) error
 	GetRoomParticipants(roomId uint) (*pbChat.RoomParticipantsResponse, error)
-	GetRoomMessages(roomId, page uint) (*pbChat.PaginatedMessagesResponse, error)
-	CreateRoom(roomName string, emails []string) (*pbChat.CreateRoomResponse, error)
-	AddRoomParticipant(roomId uint64, email string) (*pbChat.RoomParticipantsResponse, error)
+	GetRoomMessages(roomId uint, email string, query model.HistoryQuery) (*pbChat.PaginatedMessagesResponse, error)
+	CreateRoom(ownerEmail string, input model.CreateRoom) (*pbChat.CreateRoomResponse, error)
+	AddRoomParticipant(roomId uint64, callerEmail, email string) (*pbChat.RoomParticipantsResponse, error)
+	RenameRoom(roomId uint, callerEmail, roomName string) (*pbChat.RenameRoomResponse, error)
+	SetParticipantRole(roomId uint, callerEmail, email, role string) (*pbChat.User, error)
+	RemoveRoomParticipant(roomId uint, callerEmail, email string) (*pbChat.RoomMembershipResponse, error)
+	LeaveRoom(roomId uint, email string) (*pbChat.RoomMembershipResponse, error)
+	TransferOwnership(roomId uint, callerEmail, email string) (*pbChat.RoomMembershipResponse, error)
+	CreateInvite(roomId uint, email string, input model.CreateInvite) (*pbChat.Invite, error)
+	ListInvites(roomId uint, email string) (*pbChat.ListInvitesResponse, error)
+	RevokeInvite(roomId, inviteId uint, email string) (*pbChat.RevokeInviteResponse, error)
+	AcceptInvite(token, email string) (*pbChat.AcceptInviteResponse, error)
+	EditMessage(roomId, messageId uint, email, content string) (*pbChat.EditMessageResponse, error)
+	DeleteMessage(roomId, messageId uint, email string, forEveryone bool) (*pbChat.DeleteMessageResponse, error)
+	MarkRead(roomId uint, email string, upToMessageId uint) (*pbChat.MarkReadResponse, error)
+	MarkDelivered(roomId uint, email string, messageId uint) (*pbChat.MarkDeliveredResponse, error)
+	AddReaction(roomId, messageId uint, email, emoji string) (*pbChat.ReactionResponse, error)
+	RemoveReaction(roomId, messageId uint, email, emoji string) (*pbChat.ReactionResponse, error)
+	GetThread(roomId, messageId uint, email string, query model.PageQuery) (*pbChat.ThreadResponse, error)
+	PinMessage(roomId, messageId uint, email string) (*pbChat.PinMessageResponse, error)
+	UnpinMessage(roomId, messageId uint, email string) (*pbChat.PinMessageResponse, error)
+	ListPinnedMessages(roomId uint, email string) (*pbChat.PinnedMessagesResponse, error)
+	ForwardMessage(roomId, messageId uint, email string, targetRoomIds []uint) (*pbChat.ForwardMessageResponse, error)
+	SearchMessages(email string, search model.MessageSearch) (*pbChat.SearchMessagesResponse, error)
+	ListUserRooms(email string) (*pbChat.ListUserRoomsResponse, error)
+	ListMentions(email string, query model.PageQuery) (*pbChat.ListMentionsResponse, error)
+	ScheduleMessage(roomId uint, email string, input model.ScheduleMessage) (*pbChat.ScheduledMessage, error)
+	ListScheduled(roomId uint, email string) (*pbChat.ListScheduledResponse, error)
+	CancelScheduled(scheduledId uint, email string) (*pbChat.ScheduledMessage, error)
+	SetRoomRetention(roomId uint, email, retention string) (*pbChat.SetRoomRetentionResponse, error)
+	CreatePoll(roomId uint, email string, poll model.CreatePoll) (*pbChat.Poll, error)
+	Vote(roomId, pollId uint, email string, optionIds []uint) (*pbChat.Poll, error)
+	RetractVote(roomId, pollId uint, email string) (*pbChat.Poll, error)
+	ClosePoll(roomId, pollId uint, email string) (*pbChat.Poll, error)
+	CreateBot(name, email string) (*pbChat.CreateBotResponse, error)
+	InstallBot(roomId uint, name, email string) (*pbChat.Bot, error)
+	UninstallBot(roomId uint, name, email string) (*pbChat.Bot, error)
+	CreateWebhook(roomId uint, email, url string) (*pbChat.Webhook, error)
+	ListWebhooks(roomId uint, email string) (*pbChat.ListWebhooksResponse, error)
+	DeleteWebhook(roomId, webhookId uint, email string) (*pbChat.DeleteWebhookResponse, error)
+	ListWebhookDeliveries(roomId, webhookId uint, email string, query model.PageQuery) (*pbChat.ListWebhookDeliveriesResponse, error)
+	CreateIncomingWebhook(roomId uint, email, name string) (*pbChat.IncomingWebhook, error)
+	ListIncomingWebhooks(roomId uint, email string) (*pbChat.ListIncomingWebhooksResponse, error)
+	RotateIncomingWebhook(roomId, hookId uint, email string) (*pbChat.IncomingWebhook, error)
+	RevokeIncomingWebhook(roomId, hookId uint, email string) (*pbChat.RevokeIncomingWebhookResponse, error)
+	PostIncomingWebhook(token string, input model.IncomingWebhookMessage) (*pbChat.RoomMessage, error)
+	ExportRoom(ctx context.Context, roomId uint, email, format string, write func(chunk []byte) error) error
+	ImportChat(email string, input model.ImportChat, senders map[string]string, data []byte) (*pbChat.ImportChatResponse, error)
 }
 
 type chatService struct {
@@ -34,17 +83,81 @@ func (s *chatService) SaveMessage(msg *model.Message) error {
 
 	req := &pbChat.SaveMessageRequest{
 		RoomId:        uint64(msg.RoomId),
-		SenderEmail:   msg.Sender,
 		Content:       msg.Content,
 		AttachmentUrl: msg.AttachmentUrl,
 		ReplyTo:       uint64(msg.ReplyTo),
+		Type:          msg.Type,
 	}
-	res, err := chatClient.SaveMessage(context.Background(), req)
+	if err := setPayload(req, msg); err != nil {
+		return err
+	}
+	res, err := chatClient.SaveMessage(helper.CallerContext(context.Background(), msg.Sender), req)
 	if err != nil {
 		s.log.Error(err.Error())
 		return err
 	}
 	msg.Id = uint(res.MessageId)
+	msg.Command = res.Command
+	msg.Entities = ToEntities(res.Entities)
+	return nil
+}
+
+// ToEntities converts the entities of a message returned by the chat service
+func ToEntities(entities []*pbChat.MessageEntity) []model.Entity {
+	converted := make([]model.Entity, len(entities))
+	for i, e := range entities {
+		converted[i] = model.Entity{
+			Type:      e.Type,
+			Offset:    e.Offset,
+			Length:    e.Length,
+			UserEmail: e.UserEmail,
+		}
+	}
+	return converted
+}
+
+// setPayload decodes the payload of a message sent over a websocket according
+// to its type, the chat service validates its content
+func setPayload(req *pbChat.SaveMessageRequest, msg *model.Message) error {
+	if len(msg.Payload) == 0 {
+		return nil
+	}
+	switch msg.Type {
+	case model.MessageTypeLocation:
+		var location model.Location
+		if err := json.Unmarshal(msg.Payload, &location); err != nil {
+			return err
+		}
+		req.Payload = &pbChat.SaveMessageRequest_Location{Location: &pbChat.Location{
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+			Name:      location.Name,
+			Address:   location.Address,
+		}}
+	case model.MessageTypeContact:
+		var contact model.ContactCard
+		if err := json.Unmarshal(msg.Payload, &contact); err != nil {
+			return err
+		}
+		req.Payload = &pbChat.SaveMessageRequest_Contact{Contact: &pbChat.ContactCard{
+			Name:  contact.Name,
+			Email: contact.Email,
+			Phone: contact.Phone,
+		}}
+	case model.MessageTypeFile:
+		var file model.FileInfo
+		if err := json.Unmarshal(msg.Payload, &file); err != nil {
+			return err
+		}
+		req.Payload = &pbChat.SaveMessageRequest_File{File: &pbChat.FileInfo{
+			Url:      file.Url,
+			Name:     file.Name,
+			MimeType: file.MimeType,
+			Size:     file.Size,
+		}}
+	default:
+		return fmt.Errorf("message type %q does not take a payload", msg.Type)
+	}
 	return nil
 }
 
@@ -63,7 +176,7 @@ func (s *chatService) GetRoomParticipants(roomId uint) (*pbChat.RoomParticipants
 	return res, nil
 }
 
-func (s *chatService) GetRoomMessages(roomId, page uint) (*pbChat.PaginatedMessagesResponse, error) {
+func (s *chatService) GetRoomMessages(roomId uint, email string, query model.HistoryQuery) (*pbChat.PaginatedMessagesResponse, error) {
 	chatConn := helper.MustConnect(s.serviceUrl)
 	defer chatConn.Close()
 
@@ -71,10 +184,12 @@ func (s *chatService) GetRoomMessages(roomId, page uint) (*pbChat.PaginatedMessa
 
 	req := &pbChat.GetMessagesRequest{
 		RoomId: uint64(roomId),
-		Limit:  10,
-		Page:   uint32(page),
+		Limit:  uint32(query.Limit),
+		Page:   uint32(query.Page),
+		Before: query.Before,
+		After:  query.After,
 	}
-	res, err := chatClient.GetRoomMessages(context.Background(), req)
+	res, err := chatClient.GetRoomMessages(helper.CallerContext(context.Background(), email), req)
 	if err != nil {
 		s.log.Error(err.Error())
 		return nil, err
@@ -82,17 +197,19 @@ func (s *chatService) GetRoomMessages(roomId, page uint) (*pbChat.PaginatedMessa
 	return res, nil
 }
 
-func (s *chatService) CreateRoom(roomName string, emails []string) (*pbChat.CreateRoomResponse, error) {
+// CreateRoom creates a room owned by ownerEmail
+func (s *chatService) CreateRoom(ownerEmail string, input model.CreateRoom) (*pbChat.CreateRoomResponse, error) {
 	chatConn := helper.MustConnect(s.serviceUrl)
 	defer chatConn.Close()
 
 	chatClient := pbChat.NewChatServiceClient(chatConn)
 
 	req := &pbChat.CreateRoomRequest{
-		RoomName:   roomName,
-		UserEmails: emails,
+		RoomName:   input.RoomName,
+		UserEmails: input.Emails,
+		PinLimit:   input.PinLimit,
 	}
-	res, err := chatClient.CreateRoom(context.Background(), req)
+	res, err := chatClient.CreateRoom(helper.CallerContext(context.Background(), ownerEmail), req)
 	if err != nil {
 		s.log.Error(err.Error())
 		return nil, err
@@ -100,7 +217,8 @@ func (s *chatService) CreateRoom(roomName string, emails []string) (*pbChat.Crea
 	return res, nil
 }
 
-func (s *chatService) AddRoomParticipant(roomId uint64, email string) (*pbChat.RoomParticipantsResponse, error) {
+// AddRoomParticipant adds email to the room, callerEmail must be one of its admins
+func (s *chatService) AddRoomParticipant(roomId uint64, callerEmail, email string) (*pbChat.RoomParticipantsResponse, error) {
 	chatConn := helper.MustConnect(s.serviceUrl)
 	defer chatConn.Close()
 
@@ -110,7 +228,883 @@ func (s *chatService) AddRoomParticipant(roomId uint64, email string) (*pbChat.R
 		RoomId:    roomId,
 		UserEmail: email,
 	}
-	res, err := chatClient.AddRoomParticipant(context.Background(), req)
+	res, err := chatClient.AddRoomParticipant(helper.CallerContext(context.Background(), callerEmail), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+// RenameRoom renames the room, callerEmail must be one of its admins
+func (s *chatService) RenameRoom(roomId uint, callerEmail, roomName string) (*pbChat.RenameRoomResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.RenameRoomRequest{
+		RoomId:   uint64(roomId),
+		RoomName: roomName,
+	}
+	res, err := chatClient.RenameRoom(helper.CallerContext(context.Background(), callerEmail), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+// SetParticipantRole makes email an admin or a member, callerEmail must own the room
+func (s *chatService) SetParticipantRole(roomId uint, callerEmail, email, role string) (*pbChat.User, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.SetParticipantRoleRequest{
+		RoomId:    uint64(roomId),
+		UserEmail: email,
+		Role:      role,
+	}
+	res, err := chatClient.SetParticipantRole(helper.CallerContext(context.Background(), callerEmail), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+// RemoveRoomParticipant removes email from the room, callerEmail must be one of its admins
+func (s *chatService) RemoveRoomParticipant(roomId uint, callerEmail, email string) (*pbChat.RoomMembershipResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.RoomMemberRequest{
+		RoomId:    uint64(roomId),
+		UserEmail: email,
+	}
+	res, err := chatClient.RemoveRoomParticipant(helper.CallerContext(context.Background(), callerEmail), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) LeaveRoom(roomId uint, email string) (*pbChat.RoomMembershipResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.LeaveRoomRequest{RoomId: uint64(roomId)}
+	res, err := chatClient.LeaveRoom(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+// TransferOwnership hands the room to email, callerEmail must own it
+func (s *chatService) TransferOwnership(roomId uint, callerEmail, email string) (*pbChat.RoomMembershipResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.RoomMemberRequest{
+		RoomId:    uint64(roomId),
+		UserEmail: email,
+	}
+	res, err := chatClient.TransferOwnership(helper.CallerContext(context.Background(), callerEmail), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) CreateInvite(roomId uint, email string, input model.CreateInvite) (*pbChat.Invite, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.CreateInviteRequest{
+		RoomId:    uint64(roomId),
+		Role:      input.Role,
+		MaxUses:   input.MaxUses,
+		ExpiresAt: input.ExpiresAt,
+	}
+	res, err := chatClient.CreateInvite(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ListInvites(roomId uint, email string) (*pbChat.ListInvitesResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ListInvitesRequest{RoomId: uint64(roomId)}
+	res, err := chatClient.ListInvites(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) RevokeInvite(roomId, inviteId uint, email string) (*pbChat.RevokeInviteResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.InviteRequest{
+		RoomId:   uint64(roomId),
+		InviteId: uint64(inviteId),
+	}
+	res, err := chatClient.RevokeInvite(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+// AcceptInvite joins email to the room of the invite token
+func (s *chatService) AcceptInvite(token, email string) (*pbChat.AcceptInviteResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.AcceptInviteRequest{Token: token}
+	res, err := chatClient.AcceptInvite(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) EditMessage(roomId, messageId uint, email, content string) (*pbChat.EditMessageResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.EditMessageRequest{
+		RoomId:    uint64(roomId),
+		MessageId: uint64(messageId),
+		Content:   content,
+	}
+	res, err := chatClient.EditMessage(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) DeleteMessage(roomId, messageId uint, email string, forEveryone bool) (*pbChat.DeleteMessageResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	scope := pbChat.DeleteScope_DELETE_SCOPE_ME
+	if forEveryone {
+		scope = pbChat.DeleteScope_DELETE_SCOPE_EVERYONE
+	}
+	req := &pbChat.DeleteMessageRequest{
+		RoomId:    uint64(roomId),
+		MessageId: uint64(messageId),
+		Scope:     scope,
+	}
+	res, err := chatClient.DeleteMessage(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) MarkRead(roomId uint, email string, upToMessageId uint) (*pbChat.MarkReadResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.MarkReadRequest{
+		RoomId:        uint64(roomId),
+		UpToMessageId: uint64(upToMessageId),
+	}
+	res, err := chatClient.MarkRead(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) MarkDelivered(roomId uint, email string, messageId uint) (*pbChat.MarkDeliveredResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.MarkDeliveredRequest{
+		RoomId:    uint64(roomId),
+		MessageId: uint64(messageId),
+	}
+	res, err := chatClient.MarkDelivered(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) AddReaction(roomId, messageId uint, email, emoji string) (*pbChat.ReactionResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ReactionRequest{
+		RoomId:    uint64(roomId),
+		MessageId: uint64(messageId),
+		Emoji:     emoji,
+	}
+	res, err := chatClient.AddReaction(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) RemoveReaction(roomId, messageId uint, email, emoji string) (*pbChat.ReactionResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ReactionRequest{
+		RoomId:    uint64(roomId),
+		MessageId: uint64(messageId),
+		Emoji:     emoji,
+	}
+	res, err := chatClient.RemoveReaction(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) GetThread(roomId, messageId uint, email string, query model.PageQuery) (*pbChat.ThreadResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.GetThreadRequest{
+		RoomId:    uint64(roomId),
+		MessageId: uint64(messageId),
+		Limit:     uint32(query.Limit),
+		Page:      uint32(query.Page),
+	}
+	res, err := chatClient.GetThread(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) PinMessage(roomId, messageId uint, email string) (*pbChat.PinMessageResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.PinMessageRequest{
+		RoomId:    uint64(roomId),
+		MessageId: uint64(messageId),
+	}
+	res, err := chatClient.PinMessage(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) UnpinMessage(roomId, messageId uint, email string) (*pbChat.PinMessageResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.PinMessageRequest{
+		RoomId:    uint64(roomId),
+		MessageId: uint64(messageId),
+	}
+	res, err := chatClient.UnpinMessage(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ListPinnedMessages(roomId uint, email string) (*pbChat.PinnedMessagesResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ListPinnedMessagesRequest{
+		RoomId: uint64(roomId),
+	}
+	res, err := chatClient.ListPinnedMessages(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ForwardMessage(roomId, messageId uint, email string, targetRoomIds []uint) (*pbChat.ForwardMessageResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	targets := make([]uint64, len(targetRoomIds))
+	for i, id := range targetRoomIds {
+		targets[i] = uint64(id)
+	}
+	req := &pbChat.ForwardMessageRequest{
+		RoomId:        uint64(roomId),
+		MessageId:     uint64(messageId),
+		TargetRoomIds: targets,
+	}
+	res, err := chatClient.ForwardMessage(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) SearchMessages(email string, search model.MessageSearch) (*pbChat.SearchMessagesResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.SearchMessagesRequest{
+		Query:       search.Query,
+		RoomId:      uint64(search.RoomId),
+		SenderEmail: search.Sender,
+		From:        search.From,
+		To:          search.To,
+		Limit:       uint32(search.Limit),
+		Page:        uint32(search.Page),
+	}
+	res, err := chatClient.SearchMessages(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ListUserRooms(email string) (*pbChat.ListUserRoomsResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ListUserRoomsRequest{}
+	res, err := chatClient.ListUserRooms(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ListMentions(email string, query model.PageQuery) (*pbChat.ListMentionsResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ListMentionsRequest{
+		Limit: uint32(query.Limit),
+		Page:  uint32(query.Page),
+	}
+	res, err := chatClient.ListMentions(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ScheduleMessage(roomId uint, email string, input model.ScheduleMessage) (*pbChat.ScheduledMessage, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ScheduleMessageRequest{
+		RoomId:        uint64(roomId),
+		Content:       input.Content,
+		AttachmentUrl: input.AttachmentUrl,
+		ReplyTo:       uint64(input.ReplyTo),
+		SendAt:        input.SendAt,
+	}
+	res, err := chatClient.ScheduleMessage(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ListScheduled(roomId uint, email string) (*pbChat.ListScheduledResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ListScheduledRequest{
+		RoomId: uint64(roomId),
+	}
+	res, err := chatClient.ListScheduled(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) CancelScheduled(scheduledId uint, email string) (*pbChat.ScheduledMessage, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.CancelScheduledRequest{
+		ScheduledId: uint64(scheduledId),
+	}
+	res, err := chatClient.CancelScheduled(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) SetRoomRetention(roomId uint, email, retention string) (*pbChat.SetRoomRetentionResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.SetRoomRetentionRequest{
+		RoomId:    uint64(roomId),
+		Retention: retention,
+	}
+	res, err := chatClient.SetRoomRetention(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) CreatePoll(roomId uint, email string, poll model.CreatePoll) (*pbChat.Poll, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.CreatePollRequest{
+		RoomId:         uint64(roomId),
+		Question:       poll.Question,
+		Options:        poll.Options,
+		MultipleChoice: poll.MultipleChoice,
+		Anonymous:      poll.Anonymous,
+	}
+	res, err := chatClient.CreatePoll(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) Vote(roomId, pollId uint, email string, optionIds []uint) (*pbChat.Poll, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	ids := make([]uint64, len(optionIds))
+	for i, id := range optionIds {
+		ids[i] = uint64(id)
+	}
+	req := &pbChat.VoteRequest{
+		RoomId:    uint64(roomId),
+		PollId:    uint64(pollId),
+		OptionIds: ids,
+	}
+	res, err := chatClient.Vote(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) RetractVote(roomId, pollId uint, email string) (*pbChat.Poll, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.PollRequest{
+		RoomId: uint64(roomId),
+		PollId: uint64(pollId),
+	}
+	res, err := chatClient.RetractVote(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ClosePoll(roomId, pollId uint, email string) (*pbChat.Poll, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.PollRequest{
+		RoomId: uint64(roomId),
+		PollId: uint64(pollId),
+	}
+	res, err := chatClient.ClosePoll(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) CreateBot(name, email string) (*pbChat.CreateBotResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.CreateBotRequest{
+		Name: name,
+	}
+	res, err := chatClient.CreateBot(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) InstallBot(roomId uint, name, email string) (*pbChat.Bot, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.BotInstallRequest{
+		RoomId:  uint64(roomId),
+		BotName: name,
+	}
+	res, err := chatClient.InstallBot(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) UninstallBot(roomId uint, name, email string) (*pbChat.Bot, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.BotInstallRequest{
+		RoomId:  uint64(roomId),
+		BotName: name,
+	}
+	res, err := chatClient.UninstallBot(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) CreateWebhook(roomId uint, email, url string) (*pbChat.Webhook, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.CreateWebhookRequest{
+		RoomId: uint64(roomId),
+		Url:    url,
+	}
+	res, err := chatClient.CreateWebhook(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ListWebhooks(roomId uint, email string) (*pbChat.ListWebhooksResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ListWebhooksRequest{
+		RoomId: uint64(roomId),
+	}
+	res, err := chatClient.ListWebhooks(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) DeleteWebhook(roomId, webhookId uint, email string) (*pbChat.DeleteWebhookResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.WebhookRequest{
+		RoomId:    uint64(roomId),
+		WebhookId: uint64(webhookId),
+	}
+	res, err := chatClient.DeleteWebhook(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ListWebhookDeliveries(roomId, webhookId uint, email string, query model.PageQuery) (*pbChat.ListWebhookDeliveriesResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ListWebhookDeliveriesRequest{
+		RoomId:    uint64(roomId),
+		WebhookId: uint64(webhookId),
+		Limit:     uint32(query.Limit),
+		Page:      uint32(query.Page),
+	}
+	res, err := chatClient.ListWebhookDeliveries(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) CreateIncomingWebhook(roomId uint, email, name string) (*pbChat.IncomingWebhook, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.CreateIncomingWebhookRequest{
+		RoomId: uint64(roomId),
+		Name:   name,
+	}
+	res, err := chatClient.CreateIncomingWebhook(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) ListIncomingWebhooks(roomId uint, email string) (*pbChat.ListIncomingWebhooksResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.ListWebhooksRequest{
+		RoomId: uint64(roomId),
+	}
+	res, err := chatClient.ListIncomingWebhooks(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) RotateIncomingWebhook(roomId, hookId uint, email string) (*pbChat.IncomingWebhook, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.IncomingWebhookRequest{
+		RoomId: uint64(roomId),
+		HookId: uint64(hookId),
+	}
+	res, err := chatClient.RotateIncomingWebhook(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) RevokeIncomingWebhook(roomId, hookId uint, email string) (*pbChat.RevokeIncomingWebhookResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.IncomingWebhookRequest{
+		RoomId: uint64(roomId),
+		HookId: uint64(hookId),
+	}
+	res, err := chatClient.RevokeIncomingWebhook(helper.CallerContext(context.Background(), email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *chatService) PostIncomingWebhook(token string, input model.IncomingWebhookMessage) (*pbChat.RoomMessage, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	req := &pbChat.PostIncomingWebhookRequest{
+		Token:       token,
+		Text:        input.Text,
+		Username:    input.Username,
+		Attachments: make([]*pbChat.HookAttachment, len(input.Attachments)),
+	}
+	for i, a := range input.Attachments {
+		req.Attachments[i] = &pbChat.HookAttachment{
+			Fallback:  a.Fallback,
+			Color:     a.Color,
+			Pretext:   a.Pretext,
+			Title:     a.Title,
+			TitleLink: a.TitleLink,
+			Text:      a.Text,
+			ImageUrl:  a.ImageUrl,
+		}
+	}
+	res, err := chatClient.PostIncomingWebhook(context.Background(), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+// ExportRoom streams the transcript of a room to write chunk by chunk. The
+// stream stops when ctx is done.
+func (s *chatService) ExportRoom(ctx context.Context, roomId uint, email, format string, write func(chunk []byte) error) error {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	exportFormat := pbChat.ExportFormat_EXPORT_FORMAT_JSONL
+	switch format {
+	case model.ExportFormatHTML:
+		exportFormat = pbChat.ExportFormat_EXPORT_FORMAT_HTML
+	case model.ExportFormatText:
+		exportFormat = pbChat.ExportFormat_EXPORT_FORMAT_TEXT
+	}
+	req := &pbChat.ExportRoomRequest{
+		RoomId: uint64(roomId),
+		Format: exportFormat,
+	}
+	stream, err := chatClient.ExportRoom(helper.CallerContext(ctx, email), req)
+	if err != nil {
+		s.log.Error(err.Error())
+		return err
+	}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			s.log.Error(err.Error())
+			return err
+		}
+		if err := write(chunk.Data); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *chatService) ImportChat(email string, input model.ImportChat, senders map[string]string, data []byte) (*pbChat.ImportChatResponse, error) {
+	chatConn := helper.MustConnect(s.serviceUrl)
+	defer chatConn.Close()
+
+	chatClient := pbChat.NewChatServiceClient(chatConn)
+
+	source := pbChat.ImportSource_IMPORT_SOURCE_WHATSAPP
+	if input.Source == model.ImportSourceTelegram {
+		source = pbChat.ImportSource_IMPORT_SOURCE_TELEGRAM
+	}
+	req := &pbChat.ImportChatRequest{
+		Source:   source,
+		RoomName: input.RoomName,
+		Data:     data,
+		TimeZone: input.TimeZone,
+	}
+	for name, senderEmail := range senders {
+		req.Senders = append(req.Senders, &pbChat.SenderMapping{Name: name, Email: senderEmail})
+	}
+	res, err := chatClient.ImportChat(helper.CallerContext(context.Background(), email), req)
 	if err != nil {
 		s.log.Error(err.Error())
 		return nil, err
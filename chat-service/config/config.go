@@ -7,12 +7,25 @@ import (
 )
 
 type Config struct {
-	AppDebug        bool
-	DB              DatabaseConfig
-	RedisConfig     RedisConfig
-	GrpcIp          string
-	GrpcPort        string
-	ShutdownTimeout int
+	AppDebug         bool
+	DB               DatabaseConfig
+	RedisConfig      RedisConfig
+	GrpcIp           string
+	GrpcPort         string
+	ShutdownTimeout  int
+	PinLimit         int
+	ScheduleInterval int // seconds
+	SweepInterval    int // seconds
+	// LinkPreviewTimeout bounds fetching one page, in seconds
+	LinkPreviewTimeout  int
+	LinkPreviewMaxBytes int64
+	WebhookInterval     int // seconds
+	// WebhookAllowPrivate lets webhooks reach private addresses, for development
+	WebhookAllowPrivate bool
+	// ImportMaxBytes bounds the export files chats are imported from
+	ImportMaxBytes int
+	// AttachmentDeleteURL is the file API endpoint removing expired attachments
+	AttachmentDeleteURL string
 }
 
 type DatabaseConfig struct {
@@ -55,12 +68,23 @@ func LoadConfig() (Config, error) {
 
 	// add value to the config
 	config := Config{
-		DB:              loadDatabaseConfig(),
-		AppDebug:        viper.GetBool("APP_DEBUG"),
-		GrpcIp:          viper.GetString("GRPC_IP"),
-		GrpcPort:        viper.GetString("GRPC_PORT"),
-		ShutdownTimeout: viper.GetInt("SHUTDOWN_TIMEOUT"),
-		RedisConfig:     loadRedisConfig(),
+		DB:               loadDatabaseConfig(),
+		AppDebug:         viper.GetBool("APP_DEBUG"),
+		GrpcIp:           viper.GetString("GRPC_IP"),
+		GrpcPort:         viper.GetString("GRPC_PORT"),
+		ShutdownTimeout:  viper.GetInt("SHUTDOWN_TIMEOUT"),
+		RedisConfig:      loadRedisConfig(),
+		PinLimit:         viper.GetInt("PIN_LIMIT"),
+		ScheduleInterval: viper.GetInt("SCHEDULE_INTERVAL"),
+		SweepInterval:    viper.GetInt("RETENTION_SWEEP_INTERVAL"),
+
+		LinkPreviewTimeout:  viper.GetInt("LINK_PREVIEW_TIMEOUT"),
+		LinkPreviewMaxBytes: viper.GetInt64("LINK_PREVIEW_MAX_BYTES"),
+		WebhookInterval:     viper.GetInt("WEBHOOK_INTERVAL"),
+		WebhookAllowPrivate: viper.GetBool("WEBHOOK_ALLOW_PRIVATE"),
+		ImportMaxBytes:      viper.GetInt("IMPORT_MAX_BYTES"),
+
+		AttachmentDeleteURL: viper.GetString("ATTACHMENT_DELETE_URL"),
 	}
 	return config, nil
 }
@@ -95,6 +119,14 @@ func setDefaultValues() {
 	viper.SetDefault("GRPC_IP", "0.0.0.0")
 	viper.SetDefault("GRPC_PORT", ":50153")
 	viper.SetDefault("SHUTDOWN_TIMEOUT", 5)
+	viper.SetDefault("PIN_LIMIT", 3)
+	viper.SetDefault("SCHEDULE_INTERVAL", 5)
+	viper.SetDefault("RETENTION_SWEEP_INTERVAL", 60)
+	viper.SetDefault("LINK_PREVIEW_TIMEOUT", 5)
+	viper.SetDefault("LINK_PREVIEW_MAX_BYTES", 512*1024)
+	viper.SetDefault("WEBHOOK_INTERVAL", 2)
+	viper.SetDefault("WEBHOOK_ALLOW_PRIVATE", false)
+	viper.SetDefault("IMPORT_MAX_BYTES", 16*1024*1024)
 
 	viper.SetDefault("DB_MIGRATE", false)
 	viper.SetDefault("DB_SEEDING", false)
@@ -21,6 +21,14 @@ func Migrate(db *gorm.DB) error {
 		return err
 	}
 
+	if err = createIndexes(db); err != nil {
+		return err
+	}
+
+	if err = backfillRoomOwners(db); err != nil {
+		return err
+	}
+
 	return createViews(db)
 }
 
@@ -29,11 +37,51 @@ func autoMigrates(db *gorm.DB) error {
 		&model.Room{},
 		&model.RoomParticipant{},
 		&model.Message{},
+		&model.MessageRevision{},
+		&model.HiddenMessage{},
+		&model.MessageReceipt{},
+		&model.Reaction{},
+		&model.PinnedMessage{},
+		&model.Mention{},
+		&model.ScheduledMessage{},
+		&model.Poll{},
+		&model.PollOption{},
+		&model.PollVote{},
+		&model.LinkPreview{},
+		&model.Bot{},
+		&model.BotCommand{},
+		&model.BotInstall{},
+		&model.BotInvocation{},
+		&model.RoomWebhook{},
+		&model.WebhookDelivery{},
+		&model.IncomingWebhook{},
+		&model.ChatImport{},
+		&model.RoomInvite{},
 	)
 }
 
 func dropTables(db *gorm.DB) error {
 	return db.Migrator().DropTable(
+		&model.RoomInvite{},
+		&model.ChatImport{},
+		&model.IncomingWebhook{},
+		&model.WebhookDelivery{},
+		&model.RoomWebhook{},
+		&model.BotInvocation{},
+		&model.BotInstall{},
+		&model.BotCommand{},
+		&model.Bot{},
+		&model.LinkPreview{},
+		&model.PollVote{},
+		&model.PollOption{},
+		&model.Poll{},
+		&model.ScheduledMessage{},
+		&model.Mention{},
+		&model.PinnedMessage{},
+		&model.Reaction{},
+		&model.MessageReceipt{},
+		&model.HiddenMessage{},
+		&model.MessageRevision{},
 		&model.Message{},
 		&model.RoomParticipant{},
 		&model.Room{},
@@ -46,6 +94,39 @@ func setupJoinTables(db *gorm.DB) error {
 	return err
 }
 
+// createIndexes adds the indexes gorm tags cannot express
+func createIndexes(db *gorm.DB) error {
+	// full-text search over message content, must match repository.messageSearchVector
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_content_search
+		ON messages USING GIN (to_tsvector('simple', content))`).Error; err != nil {
+		return err
+	}
+
+	// keyset pagination of room history
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_room_history
+		ON messages (room_id, created_at, id)`).Error; err != nil {
+		return err
+	}
+
+	// a user joins a room once, even when accepting invites concurrently
+	return db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_room_participants_member
+		ON room_participants (room_id, user_email) WHERE deleted_at IS NULL`).Error
+}
+
+// backfillRoomOwners makes the earliest participant the owner of every room
+// that has none, rooms created before participants had roles included
+func backfillRoomOwners(db *gorm.DB) error {
+	return db.Exec(`UPDATE room_participants SET role = ?
+		WHERE id IN (
+			SELECT MIN(p.id) FROM room_participants p
+			WHERE p.deleted_at IS NULL AND NOT EXISTS (
+				SELECT 1 FROM room_participants o
+				WHERE o.room_id = p.room_id AND o.role = ? AND o.deleted_at IS NULL
+			)
+			GROUP BY p.room_id
+		)`, model.RoleOwner, model.RoleOwner).Error
+}
+
 func createViews(db *gorm.DB) error {
 	var err error
 
@@ -140,12 +140,27 @@ func (c *Cacher) Publish(channelName string, message string) error {
 	return c.rdb.Publish(context.Background(), channelName, message).Err()
 }
 
+// PublishCount publishes a message and returns how many subscribers received it
+func (c *Cacher) PublishCount(channelName string, message string) (int64, error) {
+	return c.rdb.Publish(context.Background(), channelName, message).Result()
+}
+
 func (c *Cacher) Subcribe(channelName string) (*redis.Message, error) {
 	subscriber := c.rdb.Subscribe(context.Background(), channelName)
 	message, err := subscriber.ReceiveMessage(context.Background())
 	return message, err
 }
 
+// Listen subscribes to a channel until ctx is done
+func (c *Cacher) Listen(ctx context.Context, channelName string) <-chan *redis.Message {
+	subscriber := c.rdb.Subscribe(ctx, channelName)
+	go func() {
+		<-ctx.Done()
+		subscriber.Close()
+	}()
+	return subscriber.Channel()
+}
+
 func (c *Cacher) Range(key string, start, stop int64) ([]string, error) {
 	result, err := c.rdb.LRange(context.Background(), key, start, stop).Result()
 	return result, err
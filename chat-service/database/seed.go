@@ -29,5 +29,6 @@ func dataSeeds() []interface{} {
 		seed.RoomSeed(),
 		seed.RoomParticipantSeed(),
 		seed.MessageSeed(),
+		seed.MessageReceiptSeed(),
 	}
 }
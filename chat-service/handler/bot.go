@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func (h *ChatHandler) CreateBot(ctx context.Context, req *pb.CreateBotRequest) (*pb.CreateBotResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("CreateBot request",
+		zap.String("name", req.Name),
+		zap.String("caller", caller),
+	)
+
+	bot, token, err := h.Service.BotService.CreateBot(req.Name, caller)
+	if err != nil {
+		h.Logger.Error("Failed to create bot", zap.String("name", req.Name), zap.Error(err))
+		return nil, serviceError(err, "bot")
+	}
+	return &pb.CreateBotResponse{
+		Bot:   toPbBot(bot),
+		Token: token,
+	}, nil
+}
+
+func (h *ChatHandler) RegisterBot(ctx context.Context, req *pb.RegisterBotRequest) (*pb.Bot, error) {
+	h.Logger.Info("RegisterBot request", zap.Int("commands", len(req.Commands)))
+
+	commands := make([]model.BotCommand, len(req.Commands))
+	for i, c := range req.Commands {
+		commands[i] = model.BotCommand{
+			Command:     c.Command,
+			Description: c.Description,
+		}
+	}
+
+	bot, err := h.Service.BotService.RegisterBot(req.BotToken, commands)
+	if err != nil {
+		h.Logger.Error("Failed to register bot", zap.Error(err))
+		return nil, serviceError(err, "bot")
+	}
+	return toPbBot(bot), nil
+}
+
+func (h *ChatHandler) InstallBot(ctx context.Context, req *pb.BotInstallRequest) (*pb.Bot, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("InstallBot request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("botName", req.BotName),
+		zap.String("caller", caller),
+	)
+
+	bot, err := h.Service.BotService.InstallBot(uint(req.RoomId), req.BotName, caller)
+	if err != nil {
+		h.Logger.Error("Failed to install bot", zap.Uint64("roomId", req.RoomId), zap.String("botName", req.BotName), zap.Error(err))
+		return nil, serviceError(err, "bot")
+	}
+	return toPbBot(bot), nil
+}
+
+func (h *ChatHandler) UninstallBot(ctx context.Context, req *pb.BotInstallRequest) (*pb.Bot, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("UninstallBot request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("botName", req.BotName),
+		zap.String("caller", caller),
+	)
+
+	bot, err := h.Service.BotService.UninstallBot(uint(req.RoomId), req.BotName, caller)
+	if err != nil {
+		h.Logger.Error("Failed to uninstall bot", zap.Uint64("roomId", req.RoomId), zap.String("botName", req.BotName), zap.Error(err))
+		return nil, serviceError(err, "bot")
+	}
+	return toPbBot(bot), nil
+}
+
+// ListenCommands streams the commands sent to a bot until it disconnects
+func (h *ChatHandler) ListenCommands(req *pb.BotTokenRequest, stream grpc.ServerStreamingServer[pb.CommandInvocation]) error {
+	h.Logger.Info("ListenCommands request")
+
+	err := h.Service.BotService.ListenCommands(stream.Context(), req.BotToken, func(invocation model.BotInvocation) error {
+		return stream.Send(&pb.CommandInvocation{
+			InvocationId: uint64(invocation.ID),
+			Command:      invocation.Command,
+			Args:         invocation.Args,
+			RoomId:       uint64(invocation.RoomID),
+			UserEmail:    invocation.UserEmail,
+			ExpiresAt:    invocation.ExpiresAt.String(),
+		})
+	})
+	if err != nil && stream.Context().Err() == nil {
+		h.Logger.Error("Bot command stream failed", zap.Error(err))
+		return serviceError(err, "bot")
+	}
+	return nil
+}
+
+func (h *ChatHandler) PostBotMessage(ctx context.Context, req *pb.PostBotMessageRequest) (*pb.Message, error) {
+	h.Logger.Info("PostBotMessage request", zap.Uint64("invocationId", req.InvocationId))
+
+	message, err := h.Service.BotService.PostBotMessage(req.BotToken, uint(req.InvocationId), req.Content)
+	if err != nil {
+		h.Logger.Error("Failed to post bot message", zap.Uint64("invocationId", req.InvocationId), zap.Error(err))
+		return nil, serviceError(err, "invocation")
+	}
+	return toPbMessage(*message, 0, ""), nil
+}
+
+func toPbBot(bot *model.Bot) *pb.Bot {
+	commands := make([]*pb.BotCommand, len(bot.Commands))
+	for i, c := range bot.Commands {
+		commands[i] = &pb.BotCommand{
+			Command:     c.Command,
+			Description: c.Description,
+		}
+	}
+	return &pb.Bot{
+		BotId:      uint64(bot.ID),
+		Name:       bot.Name,
+		OwnerEmail: bot.OwnerEmail,
+		Commands:   commands,
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -9,8 +10,14 @@ import (
 	"project/chat-service/model"
 	pb "project/chat-service/proto"
 	"project/chat-service/service"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// deletedMessageContent replaces the content of messages deleted for everyone
+const deletedMessageContent = "This message was deleted"
+
 type ChatHandler struct {
 	Service service.Service
 	Logger  *zap.Logger
@@ -25,9 +32,14 @@ func NewChatHandler(service service.Service, logger *zap.Logger) *ChatHandler {
 }
 
 func (h *ChatHandler) AddRoomParticipant(ctx context.Context, req *pb.AddRoomParticipantRequest) (*pb.RoomParticipantsResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
 	h.Logger.Info("Received AddRoomParticipant request",
 		zap.Uint64("roomId", req.GetRoomId()),
 		zap.String("userEmail", req.UserEmail),
+		zap.String("caller", caller),
 	)
 
 	// Fetch room details to ensure it exists
@@ -37,6 +49,12 @@ func (h *ChatHandler) AddRoomParticipant(ctx context.Context, req *pb.AddRoomPar
 		return nil, status.Errorf(codes.NotFound, "room not found: %v", err)
 	}
 
+	// Only owners and admins may add members
+	if err := h.Service.ChatService.CheckRoomAdmin(room.ID, caller); err != nil {
+		h.Logger.Warn("Caller cannot add participants", zap.String("caller", caller), zap.Error(err))
+		return nil, serviceError(err, "room")
+	}
+
 	// Check if the user is already a participant in the room
 	existingParticipants, err := h.Service.ChatService.GetRoomParticipants(uint(req.GetRoomId()))
 	if err != nil {
@@ -55,8 +73,13 @@ func (h *ChatHandler) AddRoomParticipant(ctx context.Context, req *pb.AddRoomPar
 	newParticipant := &model.RoomParticipant{
 		RoomID:    uint(req.GetRoomId()),
 		UserEmail: req.UserEmail,
+		Role:      model.RoleMember,
 	}
-	if err := h.Service.ChatService.CreateRoomParticipant(newParticipant); err != nil {
+	err = h.Service.ChatService.CreateRoomParticipant(newParticipant)
+	if errors.Is(err, service.ErrAlreadyParticipant) {
+		return nil, status.Errorf(codes.AlreadyExists, "user already a participant in the room")
+	}
+	if err != nil {
 		h.Logger.Error("Error adding participant to room", zap.Error(err))
 		return nil, status.Errorf(codes.Internal, "failed to add participant: %v", err)
 	}
@@ -66,6 +89,11 @@ func (h *ChatHandler) AddRoomParticipant(ctx context.Context, req *pb.AddRoomPar
 		zap.String("userEmail", req.UserEmail),
 	)
 
+	joined := model.SystemPayload{Event: model.SystemEventMemberJoined, Actor: caller, Target: req.UserEmail}
+	if _, err := h.Service.ChatService.PostSystemMessage(room.ID, joined); err != nil {
+		h.Logger.Error("Failed to announce new participant", zap.Uint64("roomId", req.GetRoomId()), zap.Error(err))
+	}
+
 	// Fetch updated participants list
 	updatedParticipants, err := h.Service.ChatService.GetRoomParticipants(uint(req.GetRoomId()))
 	if err != nil {
@@ -73,27 +101,23 @@ func (h *ChatHandler) AddRoomParticipant(ctx context.Context, req *pb.AddRoomPar
 		return nil, status.Errorf(codes.Internal, "failed to fetch updated participants: %v", err)
 	}
 
-	// Prepare response with updated participants
-	users := make([]*pb.User, len(updatedParticipants))
-	for i, p := range updatedParticipants {
-		users[i] = &pb.User{
-			UserId:   uint64(p.UserID),
-			Username: p.UserEmail,
-		}
-	}
-
 	return &pb.RoomParticipantsResponse{
 		RoomId:   uint64(room.ID),
 		RoomName: room.Name,
-		Users:    users,
+		Users:    toPbUsers(updatedParticipants),
 	}, nil
 }
 
 func (h *ChatHandler) CreateRoom(ctx context.Context, req *pb.CreateRoomRequest) (*pb.CreateRoomResponse, error) {
-	h.Logger.Info("CreateRoom request received", zap.String("roomName", req.RoomName))
+	owner, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("CreateRoom request received", zap.String("roomName", req.RoomName), zap.String("owner", owner))
 
 	room := &model.Room{
-		Name: req.RoomName,
+		Name:     req.RoomName,
+		PinLimit: int(req.PinLimit),
 	}
 
 	if err := h.Service.ChatService.CreateRoom(room); err != nil {
@@ -101,10 +125,27 @@ func (h *ChatHandler) CreateRoom(ctx context.Context, req *pb.CreateRoomRequest)
 		return nil, status.Errorf(codes.Internal, "failed to create room")
 	}
 
+	// The creator owns the room, everyone else joins as a member
+	ownerParticipant := &model.RoomParticipant{
+		RoomID:    room.ID,
+		UserEmail: owner,
+		Role:      model.RoleOwner,
+	}
+	if err := h.Service.ChatService.CreateRoomParticipant(ownerParticipant); err != nil {
+		h.Logger.Error("Failed to add room owner", zap.String("email", owner), zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to create room")
+	}
+
+	added := map[string]bool{owner: true}
 	for _, email := range req.UserEmails {
+		if added[email] {
+			continue
+		}
+		added[email] = true
 		participant := &model.RoomParticipant{
 			RoomID:    room.ID,
 			UserEmail: email,
+			Role:      model.RoleMember,
 		}
 		if err := h.Service.ChatService.CreateRoomParticipant(participant); err != nil {
 			h.Logger.Error("Failed to add user", zap.String("email", email), zap.Error(err))
@@ -118,24 +159,49 @@ func (h *ChatHandler) CreateRoom(ctx context.Context, req *pb.CreateRoomRequest)
 }
 
 func (h *ChatHandler) SaveMessage(ctx context.Context, req *pb.SaveMessageRequest) (*pb.SaveMessageResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
 	h.Logger.Info("SaveMessage request", zap.Uint64("roomId", req.RoomId))
 
 	message := &model.Message{
 		RoomID:        uint(req.RoomId),
-		SenderEmail:   req.SenderEmail,
+		SenderEmail:   caller,
 		Content:       req.Content,
 		AttachmentURL: helper.Ptr(req.AttachmentUrl),
-		ReplyTo:       helper.Ptr(uint(req.ReplyTo)),
+	}
+	if req.ReplyTo != 0 {
+		message.ReplyTo = helper.Ptr(uint(req.ReplyTo))
+	}
+	if err := fromPbPayload(req, message); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	command, err := h.Service.BotService.HandleCommand(message)
+	if err != nil {
+		h.Logger.Error("Failed to run command", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "command")
+	}
+	if command != "" {
+		return &pb.SaveMessageResponse{Command: command}, nil
 	}
 
 	if err := h.Service.ChatService.SaveMessage(message); err != nil {
 		h.Logger.Error("Failed to save message", zap.Error(err))
+		if errors.Is(err, service.ErrInvalidPayload) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		if errors.Is(err, service.ErrNotParticipant) {
+			return nil, status.Errorf(codes.PermissionDenied, "%v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "failed to save message")
 	}
 
 	return &pb.SaveMessageResponse{
 		MessageId: uint64(message.ID),
 		CreatedAt: message.CreatedAt.UTC().String(),
+		Entities:  toPbEntities(message.Mentions),
 	}, nil
 }
 
@@ -150,14 +216,6 @@ func (h *ChatHandler) GetRoomParticipants(ctx context.Context, req *pb.GetRoomRe
 
 	h.Logger.Info("Successfully fetched participants", zap.Int("numParticipants", len(participants)))
 
-	users := make([]*pb.User, len(participants))
-	for i, p := range participants {
-		users[i] = &pb.User{
-			UserId:   uint64(p.UserID),
-			Username: p.UserEmail,
-		}
-	}
-
 	room, err := h.Service.ChatService.GetRoomDetails(uint(req.RoomId))
 	if err != nil {
 		h.Logger.Error("Error fetching room details", zap.Uint64("roomId", req.RoomId), zap.Error(err))
@@ -169,14 +227,27 @@ func (h *ChatHandler) GetRoomParticipants(ctx context.Context, req *pb.GetRoomRe
 	return &pb.RoomParticipantsResponse{
 		RoomId:   uint64(room.ID),
 		RoomName: room.Name,
-		Users:    users,
+		Users:    toPbUsers(participants),
 	}, nil
 }
 
 func (h *ChatHandler) GetRoomMessages(ctx context.Context, req *pb.GetMessagesRequest) (*pb.PaginatedMessagesResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
 	h.Logger.Info("Received GetRoomMessages request", zap.Uint64("roomId", req.RoomId), zap.Int("limit", int(req.Limit)), zap.Int("page", int(req.Page)))
 
-	pagination, err := h.Service.ChatService.GetRoomMessages(uint(req.RoomId), int(req.Limit), int(req.Page))
+	before, err := model.DecodeMessageCursor(req.Before)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid before cursor")
+	}
+	after, err := model.DecodeMessageCursor(req.After)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid after cursor")
+	}
+
+	pagination, err := h.Service.ChatService.GetRoomMessages(uint(req.RoomId), caller, int(req.Limit), int(req.Page), before, after)
 	if err != nil {
 		h.Logger.Error("Error fetching room messages", zap.Uint64("roomId", req.RoomId), zap.Int("limit", int(req.Limit)), zap.Int("page", int(req.Page)), zap.Error(err))
 		return nil, err
@@ -190,40 +261,15 @@ func (h *ChatHandler) GetRoomMessages(ctx context.Context, req *pb.GetMessagesRe
 
 	h.Logger.Info("Successfully fetched room details", zap.Int64("roomId", int64(room.ID)))
 
+	participants, err := h.Service.ChatService.GetRoomParticipants(uint(req.RoomId))
+	if err != nil {
+		h.Logger.Error("Error fetching room participants", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, err
+	}
+
 	var msgs []*pb.Message
 	for _, m := range pagination.Messages {
-		var attachmentURL string
-		if m.AttachmentURL != nil {
-			attachmentURL = *m.AttachmentURL
-		} else {
-			attachmentURL = ""
-		}
-
-		var replyTo int64
-		if m.ReplyTo != nil {
-			replyTo = int64(*m.ReplyTo)
-		} else {
-			replyTo = 0
-		}
-
-		var readAt string
-		if m.ReadAt != nil {
-			readAt = m.ReadAt.String()
-		} else {
-			readAt = ""
-		}
-
-		data := &pb.Message{
-			MessageId:     uint64(m.ID),
-			SenderEmail:   m.SenderEmail,
-			Content:       m.Content,
-			AttachmentUrl: attachmentURL,
-			ReplyTo:       uint64(replyTo),
-			SentAt:        m.CreatedAt.String(),
-			ReadAt:        readAt,
-		}
-
-		msgs = append(msgs, data)
+		msgs = append(msgs, toPbMessage(m, len(participants)-1, caller))
 	}
 
 	return &pb.PaginatedMessagesResponse{
@@ -236,5 +282,294 @@ func (h *ChatHandler) GetRoomMessages(ctx context.Context, req *pb.GetMessagesRe
 			TotalPages: uint32(pagination.TotalPages),
 			TotalItems: uint32(pagination.TotalItems),
 		},
+		NextCursor: pagination.NextCursor,
+		PrevCursor: pagination.PrevCursor,
+	}, nil
+}
+
+func (h *ChatHandler) EditMessage(ctx context.Context, req *pb.EditMessageRequest) (*pb.EditMessageResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("EditMessage request", zap.Uint64("roomId", req.RoomId), zap.Uint64("messageId", req.MessageId))
+
+	if req.Content == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "content is required")
+	}
+
+	message, err := h.Service.ChatService.EditMessage(uint(req.RoomId), uint(req.MessageId), caller, req.Content)
+	if err != nil {
+		h.Logger.Error("Failed to edit message", zap.Uint64("messageId", req.MessageId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	return &pb.EditMessageResponse{
+		RoomId:    uint64(message.RoomID),
+		MessageId: uint64(message.ID),
+		Content:   message.Content,
+		EditedAt:  message.EditedAt.String(),
 	}, nil
 }
+
+func (h *ChatHandler) DeleteMessage(ctx context.Context, req *pb.DeleteMessageRequest) (*pb.DeleteMessageResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("DeleteMessage request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("messageId", req.MessageId),
+		zap.String("scope", req.Scope.String()),
+	)
+
+	forEveryone := req.Scope == pb.DeleteScope_DELETE_SCOPE_EVERYONE
+	if err := h.Service.ChatService.DeleteMessage(uint(req.RoomId), uint(req.MessageId), caller, forEveryone); err != nil {
+		h.Logger.Error("Failed to delete message", zap.Uint64("messageId", req.MessageId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	return &pb.DeleteMessageResponse{
+		RoomId:    req.RoomId,
+		MessageId: req.MessageId,
+		Scope:     req.Scope,
+		UserEmail: caller,
+	}, nil
+}
+
+func (h *ChatHandler) MarkRead(ctx context.Context, req *pb.MarkReadRequest) (*pb.MarkReadResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("MarkRead request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.Uint64("upToMessageId", req.UpToMessageId),
+	)
+
+	marked, readAt, err := h.Service.ChatService.MarkRead(uint(req.RoomId), caller, uint(req.UpToMessageId))
+	if err != nil {
+		h.Logger.Error("Failed to mark messages as read", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	return &pb.MarkReadResponse{
+		RoomId:        req.RoomId,
+		UserEmail:     caller,
+		UpToMessageId: req.UpToMessageId,
+		Marked:        uint32(marked),
+		ReadAt:        readAt.String(),
+	}, nil
+}
+
+func (h *ChatHandler) MarkDelivered(ctx context.Context, req *pb.MarkDeliveredRequest) (*pb.MarkDeliveredResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("MarkDelivered request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.Uint64("messageId", req.MessageId),
+	)
+
+	message, marked, deliveredAt, err := h.Service.ChatService.MarkDelivered(uint(req.RoomId), uint(req.MessageId), caller)
+	if err != nil {
+		h.Logger.Error("Failed to mark message as delivered", zap.Uint64("messageId", req.MessageId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	return &pb.MarkDeliveredResponse{
+		RoomId:      req.RoomId,
+		UserEmail:   caller,
+		MessageId:   req.MessageId,
+		SenderEmail: message.SenderEmail,
+		Marked:      marked,
+		DeliveredAt: deliveredAt.String(),
+	}, nil
+}
+
+// serviceError maps errors returned by the services to a gRPC status. entity names
+// what the request was about in the errors that do not say it themselves.
+func serviceError(err error, entity string) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidInvite):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, service.ErrMessageNotInRoom):
+		return status.Errorf(codes.NotFound, "message not found")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Errorf(codes.NotFound, "%s not found", entity)
+	case errors.Is(err, service.ErrInvalidBotToken), errors.Is(err, service.ErrInvalidHookToken):
+		return status.Errorf(codes.Unauthenticated, "%v", err)
+	case errors.Is(err, service.ErrCommandTaken), errors.Is(err, service.ErrBotNameTaken),
+		errors.Is(err, service.ErrAlreadyParticipant):
+		return status.Errorf(codes.AlreadyExists, "%v", err)
+	case errors.Is(err, service.ErrNotMessageSender), errors.Is(err, service.ErrNotParticipant),
+		errors.Is(err, service.ErrNotPollCreator), errors.Is(err, service.ErrNotRoomAdmin),
+		errors.Is(err, service.ErrNotRoomOwner):
+		return status.Errorf(codes.PermissionDenied, "%v", err)
+	case errors.Is(err, service.ErrInvalidEmoji), errors.Is(err, service.ErrNoTargetRooms),
+		errors.Is(err, service.ErrEmptySearchQuery), errors.Is(err, service.ErrSendAtNotInFuture),
+		errors.Is(err, service.ErrInvalidRetention), errors.Is(err, service.ErrInvalidPoll),
+		errors.Is(err, service.ErrInvalidPollVote), errors.Is(err, service.ErrInvalidBotName),
+		errors.Is(err, service.ErrInvalidCommand), errors.Is(err, service.ErrEmptyBotMessage),
+		errors.Is(err, service.ErrInvalidWebhookURL), errors.Is(err, service.ErrInvalidHookName),
+		errors.Is(err, service.ErrEmptyHookMessage), errors.Is(err, service.ErrInvalidExportFormat),
+		errors.Is(err, service.ErrInvalidImportSource), errors.Is(err, service.ErrInvalidTimeZone),
+		errors.Is(err, service.ErrInvalidExport), errors.Is(err, service.ErrEmptyImport),
+		errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrInvalidRoomName),
+		errors.Is(err, service.ErrInvalidNewOwner), errors.Is(err, service.ErrInvalidInviteUses),
+		errors.Is(err, service.ErrInvalidInviteExpiry):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, service.ErrPinLimitReached), errors.Is(err, service.ErrScheduledNotPending),
+		errors.Is(err, service.ErrPollClosed), errors.Is(err, service.ErrInvocationExpired),
+		errors.Is(err, service.ErrOwnerRole), errors.Is(err, service.ErrRemoveOwner),
+		errors.Is(err, service.ErrOwnerMustLeave), errors.Is(err, service.ErrInviteExpired),
+		errors.Is(err, service.ErrInviteUsedUp), errors.Is(err, service.ErrBotNotInstalled),
+		errors.Is(err, service.ErrNotEditable):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	default:
+		return status.Errorf(codes.Internal, "failed to process %s", entity)
+	}
+}
+
+// toPbMessage converts a stored message, with its receipts and reactions preloaded,
+// into its protobuf form as seen by userEmail. recipients is the number of
+// participants other than the sender.
+func toPbMessage(m model.Message, recipients int, userEmail string) *pb.Message {
+	var attachmentURL string
+	if m.AttachmentURL != nil {
+		attachmentURL = *m.AttachmentURL
+	}
+
+	var replyTo uint64
+	if m.ReplyTo != nil {
+		replyTo = uint64(*m.ReplyTo)
+	}
+
+	var readAt string
+	var firstRead *time.Time
+	var deliveredCount int
+	readBy := make([]*pb.ReadReceipt, 0, len(m.Receipts))
+	for _, r := range m.Receipts {
+		if r.DeliveredAt != nil {
+			deliveredCount++
+		}
+		if r.ReadAt == nil {
+			continue
+		}
+		if firstRead == nil || r.ReadAt.Before(*firstRead) {
+			firstRead = r.ReadAt
+		}
+		readBy = append(readBy, &pb.ReadReceipt{
+			UserEmail: r.UserEmail,
+			ReadAt:    r.ReadAt.String(),
+		})
+	}
+	if firstRead != nil {
+		readAt = firstRead.String()
+	}
+
+	messageStatus := model.MessageStatusSent
+	switch {
+	case recipients > 0 && len(readBy) >= recipients:
+		messageStatus = model.MessageStatusRead
+	case recipients > 0 && deliveredCount >= recipients:
+		messageStatus = model.MessageStatusDelivered
+	}
+
+	var editedAt string
+	if m.EditedAt != nil {
+		editedAt = m.EditedAt.String()
+	}
+
+	content := m.Content
+	entities := toPbEntities(m.Mentions)
+	var poll *pb.Poll
+	if m.Poll != nil {
+		poll = toPbPoll(*m.Poll, userEmail)
+	}
+	deleted := m.DeletedAt.Valid
+	if deleted {
+		content = deletedMessageContent
+		attachmentURL = ""
+		entities = nil
+		poll = nil
+	}
+
+	var forwardedFrom uint64
+	var forwardedSender string
+	if m.ForwardedFrom != nil {
+		forwardedFrom = uint64(*m.ForwardedFrom)
+	}
+	if m.ForwardedSender != nil {
+		forwardedSender = *m.ForwardedSender
+	}
+
+	var expiresAt string
+	if m.ExpiresAt != nil {
+		expiresAt = m.ExpiresAt.String()
+	}
+
+	var lastReplyAt string
+	if m.LastReplyAt != nil {
+		lastReplyAt = m.LastReplyAt.String()
+	}
+
+	var myReactions []string
+	for _, r := range m.Reactions {
+		if r.UserEmail == userEmail {
+			myReactions = append(myReactions, r.Emoji)
+		}
+	}
+
+	message := &pb.Message{
+		MessageId:           uint64(m.ID),
+		SenderEmail:         m.SenderEmail,
+		SenderType:          m.SenderType,
+		Content:             content,
+		AttachmentUrl:       attachmentURL,
+		ReplyTo:             replyTo,
+		SentAt:              m.CreatedAt.String(),
+		ReadAt:              readAt,
+		EditedAt:            editedAt,
+		Deleted:             deleted,
+		ReadBy:              readBy,
+		ReadCount:           uint32(len(readBy)),
+		DeliveredCount:      uint32(deliveredCount),
+		Status:              messageStatus,
+		Reactions:           toPbReactionCounts(model.CountReactions(m.Reactions)),
+		MyReactions:         myReactions,
+		ReplyCount:          uint32(m.ReplyCount),
+		LastReplyAt:         lastReplyAt,
+		ForwardedFrom:       forwardedFrom,
+		ForwardedFromSender: forwardedSender,
+		Entities:            entities,
+		ExpiresAt:           expiresAt,
+		Poll:                poll,
+	}
+	setPbPayload(m, message)
+	if deleted {
+		message.Payload = nil
+	} else {
+		message.Previews = toPbLinkPreviews(m.Previews)
+	}
+	return message
+}
+
+func toPbEntities(mentions []model.Mention) []*pb.MessageEntity {
+	if len(mentions) == 0 {
+		return nil
+	}
+	entities := make([]*pb.MessageEntity, len(mentions))
+	for i, m := range mentions {
+		entities[i] = &pb.MessageEntity{
+			Type:      model.EntityTypeMention,
+			Offset:    uint32(m.Offset),
+			Length:    uint32(m.Length),
+			UserEmail: m.UserEmail,
+		}
+	}
+	return entities
+}
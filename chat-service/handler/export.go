@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"bufio"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// exportChunkSize is the size of the chunks a transcript is streamed in
+const exportChunkSize = 32 * 1024
+
+var exportFormats = map[pb.ExportFormat]string{
+	pb.ExportFormat_EXPORT_FORMAT_JSONL: model.ExportFormatJSONL,
+	pb.ExportFormat_EXPORT_FORMAT_HTML:  model.ExportFormatHTML,
+	pb.ExportFormat_EXPORT_FORMAT_TEXT:  model.ExportFormatText,
+}
+
+// ExportRoom streams the transcript of a room in chunks
+func (h *ChatHandler) ExportRoom(req *pb.ExportRoomRequest, stream grpc.ServerStreamingServer[pb.ExportChunk]) error {
+	caller, err := callerEmail(stream.Context())
+	if err != nil {
+		return err
+	}
+	h.Logger.Info("ExportRoom request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.String("format", req.Format.String()),
+	)
+
+	w := bufio.NewWriterSize(chunkWriter{stream}, exportChunkSize)
+	err = h.Service.ExportService.ExportRoom(stream.Context(), uint(req.RoomId), caller, exportFormats[req.Format], w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil && stream.Context().Err() == nil {
+		h.Logger.Error("Failed to export room", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return serviceError(err, "room")
+	}
+	return nil
+}
+
+// chunkWriter sends every write as one chunk of the stream
+type chunkWriter struct {
+	stream grpc.ServerStreamingServer[pb.ExportChunk]
+}
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+	if err := w.stream.Send(&pb.ExportChunk{Data: p}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+func (h *ChatHandler) ForwardMessage(ctx context.Context, req *pb.ForwardMessageRequest) (*pb.ForwardMessageResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ForwardMessage request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("messageId", req.MessageId),
+		zap.Uint64s("targetRoomIds", req.TargetRoomIds),
+	)
+
+	targets := make([]uint, len(req.TargetRoomIds))
+	for i, id := range req.TargetRoomIds {
+		targets[i] = uint(id)
+	}
+
+	copies, err := h.Service.ChatService.ForwardMessage(uint(req.RoomId), uint(req.MessageId), caller, targets)
+	if err != nil {
+		h.Logger.Error("Failed to forward message", zap.Uint64("messageId", req.MessageId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	forwarded := make([]*pb.ForwardedMessage, len(copies))
+	for i, m := range copies {
+		forwarded[i] = &pb.ForwardedMessage{
+			RoomId:  uint64(m.RoomID),
+			Message: toPbMessage(m, 0, caller),
+		}
+	}
+
+	return &pb.ForwardMessageResponse{
+		RoomId:    req.RoomId,
+		MessageId: req.MessageId,
+		Forwarded: forwarded,
+	}, nil
+}
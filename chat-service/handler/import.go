@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+var importSources = map[pb.ImportSource]string{
+	pb.ImportSource_IMPORT_SOURCE_WHATSAPP: model.ImportSourceWhatsApp,
+	pb.ImportSource_IMPORT_SOURCE_TELEGRAM: model.ImportSourceTelegram,
+}
+
+func (h *ChatHandler) ImportChat(ctx context.Context, req *pb.ImportChatRequest) (*pb.ImportChatResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ImportChat request",
+		zap.String("caller", caller),
+		zap.String("source", req.Source.String()),
+		zap.Int("bytes", len(req.Data)),
+	)
+
+	senders := make(map[string]string, len(req.Senders))
+	for _, m := range req.Senders {
+		senders[m.Name] = m.Email
+	}
+
+	result, err := h.Service.ImportService.ImportChat(caller, importSources[req.Source], req.RoomName, req.Data, senders, req.TimeZone)
+	if err != nil {
+		h.Logger.Error("Failed to import chat", zap.String("caller", caller), zap.Error(err))
+		return nil, serviceError(err, "room")
+	}
+
+	chatImport := result.Import
+	res := &pb.ImportChatResponse{
+		ImportId:        uint64(chatImport.ID),
+		Status:          chatImport.Status,
+		Imported:        uint32(chatImport.Imported),
+		Skipped:         uint32(chatImport.Skipped),
+		Failed:          uint32(chatImport.Failed),
+		UnmappedSenders: result.UnmappedSenders,
+		AlreadyImported: result.AlreadyImported,
+	}
+	if chatImport.RoomID != nil {
+		res.RoomId = uint64(*chatImport.RoomID)
+	}
+	for _, invite := range result.Invites {
+		res.Invites = append(res.Invites, &pb.ImportInvite{Email: invite.Email, Token: invite.Token})
+	}
+	for _, e := range result.Errors {
+		res.Errors = append(res.Errors, &pb.ImportLineError{Line: uint32(e.Line), Reason: e.Reason})
+	}
+	return res, nil
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+func (h *ChatHandler) CreateIncomingWebhook(ctx context.Context, req *pb.CreateIncomingWebhookRequest) (*pb.IncomingWebhook, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("CreateIncomingWebhook request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.String("name", req.Name),
+	)
+
+	hook, token, err := h.Service.IncomingWebhookService.CreateIncomingWebhook(uint(req.RoomId), caller, req.Name)
+	if err != nil {
+		h.Logger.Error("Failed to create incoming webhook", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "webhook")
+	}
+
+	res := toPbIncomingWebhook(*hook)
+	res.Token = token
+	return res, nil
+}
+
+func (h *ChatHandler) ListIncomingWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListIncomingWebhooksResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ListIncomingWebhooks request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+	)
+
+	hooks, err := h.Service.IncomingWebhookService.ListIncomingWebhooks(uint(req.RoomId), caller)
+	if err != nil {
+		h.Logger.Error("Error fetching incoming webhooks", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "webhook")
+	}
+
+	res := make([]*pb.IncomingWebhook, len(hooks))
+	for i, hook := range hooks {
+		res[i] = toPbIncomingWebhook(hook)
+	}
+	return &pb.ListIncomingWebhooksResponse{Hooks: res}, nil
+}
+
+func (h *ChatHandler) RotateIncomingWebhook(ctx context.Context, req *pb.IncomingWebhookRequest) (*pb.IncomingWebhook, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("RotateIncomingWebhook request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("hookId", req.HookId),
+		zap.String("caller", caller),
+	)
+
+	hook, token, err := h.Service.IncomingWebhookService.RotateIncomingWebhook(uint(req.RoomId), uint(req.HookId), caller)
+	if err != nil {
+		h.Logger.Error("Failed to rotate incoming webhook", zap.Uint64("hookId", req.HookId), zap.Error(err))
+		return nil, serviceError(err, "webhook")
+	}
+
+	res := toPbIncomingWebhook(*hook)
+	res.Token = token
+	return res, nil
+}
+
+func (h *ChatHandler) RevokeIncomingWebhook(ctx context.Context, req *pb.IncomingWebhookRequest) (*pb.RevokeIncomingWebhookResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("RevokeIncomingWebhook request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("hookId", req.HookId),
+		zap.String("caller", caller),
+	)
+
+	if err := h.Service.IncomingWebhookService.RevokeIncomingWebhook(uint(req.RoomId), uint(req.HookId), caller); err != nil {
+		h.Logger.Error("Failed to revoke incoming webhook", zap.Uint64("hookId", req.HookId), zap.Error(err))
+		return nil, serviceError(err, "webhook")
+	}
+	return &pb.RevokeIncomingWebhookResponse{HookId: req.HookId}, nil
+}
+
+func (h *ChatHandler) PostIncomingWebhook(ctx context.Context, req *pb.PostIncomingWebhookRequest) (*pb.RoomMessage, error) {
+	h.Logger.Info("PostIncomingWebhook request", zap.Int("attachments", len(req.Attachments)))
+
+	message := model.HookMessage{
+		Text:        req.Text,
+		Username:    req.Username,
+		Attachments: make([]model.HookAttachment, len(req.Attachments)),
+	}
+	for i, a := range req.Attachments {
+		message.Attachments[i] = model.HookAttachment{
+			Fallback:  a.Fallback,
+			Color:     a.Color,
+			Pretext:   a.Pretext,
+			Title:     a.Title,
+			TitleLink: a.TitleLink,
+			Text:      a.Text,
+			ImageURL:  a.ImageUrl,
+		}
+	}
+
+	saved, err := h.Service.IncomingWebhookService.PostIncomingWebhook(req.Token, message)
+	if err != nil {
+		h.Logger.Error("Failed to post incoming webhook message", zap.Error(err))
+		return nil, serviceError(err, "webhook")
+	}
+	return &pb.RoomMessage{
+		RoomId:  uint64(saved.RoomID),
+		Message: toPbMessage(*saved, 0, ""),
+	}, nil
+}
+
+// toPbIncomingWebhook converts an incoming webhook without its token
+func toPbIncomingWebhook(hook model.IncomingWebhook) *pb.IncomingWebhook {
+	return &pb.IncomingWebhook{
+		HookId:    uint64(hook.ID),
+		RoomId:    uint64(hook.RoomID),
+		Name:      hook.Name,
+		CreatedBy: hook.CreatedBy,
+		CreatedAt: hook.CreatedAt.UTC().String(),
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *ChatHandler) CreateInvite(ctx context.Context, req *pb.CreateInviteRequest) (*pb.Invite, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("CreateInvite request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.String("role", req.Role),
+		zap.Uint32("maxUses", req.MaxUses),
+		zap.String("expiresAt", req.ExpiresAt),
+	)
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid expires_at: %v", err)
+		}
+		expiresAt = &t
+	}
+
+	invite, token, err := h.Service.InviteService.CreateInvite(uint(req.RoomId), caller, req.Role, int(req.MaxUses), expiresAt)
+	if err != nil {
+		h.Logger.Error("Failed to create invite", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "invite")
+	}
+
+	res := toPbInvite(*invite)
+	res.Token = token
+	return res, nil
+}
+
+func (h *ChatHandler) ListInvites(ctx context.Context, req *pb.ListInvitesRequest) (*pb.ListInvitesResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ListInvites request", zap.Uint64("roomId", req.RoomId), zap.String("caller", caller))
+
+	invites, err := h.Service.InviteService.ListInvites(uint(req.RoomId), caller)
+	if err != nil {
+		h.Logger.Error("Error fetching invites", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "invite")
+	}
+
+	res := make([]*pb.Invite, len(invites))
+	for i, invite := range invites {
+		res[i] = toPbInvite(invite)
+	}
+	return &pb.ListInvitesResponse{Invites: res}, nil
+}
+
+func (h *ChatHandler) RevokeInvite(ctx context.Context, req *pb.InviteRequest) (*pb.RevokeInviteResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("RevokeInvite request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("inviteId", req.InviteId),
+		zap.String("caller", caller),
+	)
+
+	if err := h.Service.InviteService.RevokeInvite(uint(req.RoomId), uint(req.InviteId), caller); err != nil {
+		h.Logger.Error("Failed to revoke invite", zap.Uint64("inviteId", req.InviteId), zap.Error(err))
+		return nil, serviceError(err, "invite")
+	}
+	return &pb.RevokeInviteResponse{InviteId: req.InviteId}, nil
+}
+
+func (h *ChatHandler) AcceptInvite(ctx context.Context, req *pb.AcceptInviteRequest) (*pb.AcceptInviteResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("AcceptInvite request", zap.String("caller", caller))
+
+	participant, err := h.Service.InviteService.AcceptInvite(req.Token, caller)
+	if err != nil {
+		h.Logger.Warn("Failed to accept invite", zap.String("caller", caller), zap.Error(err))
+		return nil, serviceError(err, "invite")
+	}
+
+	room, err := h.Service.ChatService.GetRoomDetails(participant.RoomID)
+	if err != nil {
+		h.Logger.Error("Error fetching room details", zap.Uint("roomId", participant.RoomID), zap.Error(err))
+		return nil, serviceError(err, "invite")
+	}
+
+	return &pb.AcceptInviteResponse{
+		RoomId:   uint64(room.ID),
+		RoomName: room.Name,
+		User:     toPbUser(*participant),
+	}, nil
+}
+
+// toPbInvite converts an invite without its token
+func toPbInvite(invite model.RoomInvite) *pb.Invite {
+	res := &pb.Invite{
+		InviteId:  uint64(invite.ID),
+		RoomId:    uint64(invite.RoomID),
+		Role:      invite.Role,
+		MaxUses:   uint32(invite.MaxUses),
+		Uses:      uint32(invite.Uses),
+		CreatedBy: invite.CreatedBy,
+		CreatedAt: invite.CreatedAt.UTC().String(),
+		Email:     invite.Email,
+	}
+	if invite.ExpiresAt != nil {
+		res.ExpiresAt = invite.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return res
+}
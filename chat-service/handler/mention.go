@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+func (h *ChatHandler) ListMentions(ctx context.Context, req *pb.ListMentionsRequest) (*pb.ListMentionsResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ListMentions request",
+		zap.String("caller", caller),
+		zap.Int("limit", int(req.Limit)),
+		zap.Int("page", int(req.Page)),
+	)
+
+	result, err := h.Service.ChatService.ListMentions(caller, int(req.Limit), int(req.Page))
+	if err != nil {
+		h.Logger.Error("Error fetching mentions", zap.String("caller", caller), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	recipients := make(roomRecipients)
+	msgs := make([]*pb.RoomMessage, 0, len(result.Messages))
+	for _, m := range result.Messages {
+		n, err := recipients.count(h, m.RoomID)
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, &pb.RoomMessage{
+			RoomId:  uint64(m.RoomID),
+			Message: toPbMessage(m, n, caller),
+		})
+	}
+
+	return &pb.ListMentionsResponse{
+		Messages: msgs,
+		Pagination: &pb.Pagination{
+			Page:       uint32(result.Page),
+			Limit:      uint32(result.Limit),
+			TotalPages: uint32(result.TotalPages),
+			TotalItems: uint32(result.TotalItems),
+		},
+	}, nil
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"fmt"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+)
+
+// fromPbPayload sets the type and payload of a message sent by a client. The
+// type defaults to the one of the payload; poll and system messages are only
+// created by the service itself.
+func fromPbPayload(req *pb.SaveMessageRequest, message *model.Message) error {
+	var messageType string
+	var payload any
+	switch p := req.Payload.(type) {
+	case *pb.SaveMessageRequest_Location:
+		messageType = model.MessageTypeLocation
+		payload = model.LocationPayload{
+			Latitude:  p.Location.GetLatitude(),
+			Longitude: p.Location.GetLongitude(),
+			Name:      p.Location.GetName(),
+			Address:   p.Location.GetAddress(),
+		}
+	case *pb.SaveMessageRequest_Contact:
+		messageType = model.MessageTypeContact
+		payload = model.ContactPayload{
+			Name:  p.Contact.GetName(),
+			Email: p.Contact.GetEmail(),
+			Phone: p.Contact.GetPhone(),
+		}
+	case *pb.SaveMessageRequest_File:
+		messageType = model.MessageTypeFile
+		payload = model.FilePayload{
+			URL:      p.File.GetUrl(),
+			Name:     p.File.GetName(),
+			MimeType: p.File.GetMimeType(),
+			Size:     p.File.GetSize(),
+		}
+	}
+
+	switch req.Type {
+	case model.MessageTypePoll, model.MessageTypeSystem:
+		return fmt.Errorf("%w: %s messages cannot be sent", model.ErrInvalidPayload, req.Type)
+	case "", messageType:
+	default:
+		if payload != nil {
+			return fmt.Errorf("%w: %s message with a %s payload", model.ErrInvalidPayload, req.Type, messageType)
+		}
+		// a type without its payload, refused by the payload validation
+		messageType = req.Type
+	}
+
+	if payload == nil {
+		message.Type = messageType
+		return nil
+	}
+	return message.SetPayload(messageType, payload)
+}
+
+// setPbPayload copies the type and payload of a stored message to its protobuf form
+func setPbPayload(m model.Message, message *pb.Message) {
+	message.Type = m.Type
+	if message.Type == "" {
+		message.Type = model.MessageTypeText
+	}
+
+	payload, err := m.DecodePayload()
+	if err != nil {
+		return
+	}
+	switch p := payload.(type) {
+	case *model.LocationPayload:
+		message.Payload = &pb.Message_Location{Location: &pb.Location{
+			Latitude:  p.Latitude,
+			Longitude: p.Longitude,
+			Name:      p.Name,
+			Address:   p.Address,
+		}}
+	case *model.ContactPayload:
+		message.Payload = &pb.Message_Contact{Contact: &pb.ContactCard{
+			Name:  p.Name,
+			Email: p.Email,
+			Phone: p.Phone,
+		}}
+	case *model.FilePayload:
+		message.Payload = &pb.Message_File{File: &pb.FileInfo{
+			Url:      p.URL,
+			Name:     p.Name,
+			MimeType: p.MimeType,
+			Size:     p.Size,
+		}}
+	case *model.SystemPayload:
+		message.Payload = &pb.Message_System{System: &pb.SystemEvent{
+			Event:  p.Event,
+			Actor:  p.Actor,
+			Target: p.Target,
+			Value:  p.Value,
+		}}
+	}
+}
+
+func toPbLinkPreviews(previews []model.LinkPreview) []*pb.LinkPreview {
+	if len(previews) == 0 {
+		return nil
+	}
+	pbPreviews := make([]*pb.LinkPreview, len(previews))
+	for i, p := range previews {
+		pbPreviews[i] = &pb.LinkPreview{
+			Url:         p.URL,
+			Title:       p.Title,
+			Description: p.Description,
+			ImageUrl:    p.ImageURL,
+			SiteName:    p.SiteName,
+		}
+	}
+	return pbPreviews
+}
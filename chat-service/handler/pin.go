@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+func (h *ChatHandler) PinMessage(ctx context.Context, req *pb.PinMessageRequest) (*pb.PinMessageResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("PinMessage request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("messageId", req.MessageId),
+		zap.String("caller", caller),
+	)
+
+	pin, err := h.Service.ChatService.PinMessage(uint(req.RoomId), uint(req.MessageId), caller)
+	if err != nil {
+		h.Logger.Error("Failed to pin message", zap.Uint64("messageId", req.MessageId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	return &pb.PinMessageResponse{
+		RoomId:    req.RoomId,
+		MessageId: req.MessageId,
+		UserEmail: pin.PinnedBy,
+		Pinned:    true,
+		PinnedAt:  pin.PinnedAt.String(),
+	}, nil
+}
+
+func (h *ChatHandler) UnpinMessage(ctx context.Context, req *pb.PinMessageRequest) (*pb.PinMessageResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("UnpinMessage request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("messageId", req.MessageId),
+		zap.String("caller", caller),
+	)
+
+	unpinned, err := h.Service.ChatService.UnpinMessage(uint(req.RoomId), uint(req.MessageId), caller)
+	if err != nil {
+		h.Logger.Error("Failed to unpin message", zap.Uint64("messageId", req.MessageId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+	if !unpinned {
+		h.Logger.Info("Message was not pinned", zap.Uint64("messageId", req.MessageId))
+	}
+
+	return &pb.PinMessageResponse{
+		RoomId:    req.RoomId,
+		MessageId: req.MessageId,
+		UserEmail: caller,
+		Pinned:    false,
+	}, nil
+}
+
+func (h *ChatHandler) ListPinnedMessages(ctx context.Context, req *pb.ListPinnedMessagesRequest) (*pb.PinnedMessagesResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ListPinnedMessages request", zap.Uint64("roomId", req.RoomId))
+
+	pins, limit, err := h.Service.ChatService.GetPinnedMessages(uint(req.RoomId), caller)
+	if err != nil {
+		h.Logger.Error("Error fetching pinned messages", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	participants, err := h.Service.ChatService.GetRoomParticipants(uint(req.RoomId))
+	if err != nil {
+		h.Logger.Error("Error fetching room participants", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, err
+	}
+
+	pbPins := make([]*pb.PinnedMessage, 0, len(pins))
+	for _, p := range pins {
+		pbPins = append(pbPins, &pb.PinnedMessage{
+			Message:  toPbMessage(p.Message, len(participants)-1, caller),
+			PinnedBy: p.PinnedBy,
+			PinnedAt: p.PinnedAt.String(),
+		})
+	}
+
+	return &pb.PinnedMessagesResponse{
+		RoomId:   req.RoomId,
+		PinLimit: uint32(limit),
+		Pins:     pbPins,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+func (h *ChatHandler) CreatePoll(ctx context.Context, req *pb.CreatePollRequest) (*pb.Poll, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("CreatePoll request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.Int("options", len(req.Options)),
+	)
+
+	poll, err := h.Service.PollService.CreatePoll(uint(req.RoomId), caller, req.Question, req.Options, req.MultipleChoice, req.Anonymous)
+	if err != nil {
+		h.Logger.Error("Failed to create poll", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "poll")
+	}
+	return toPbPoll(*poll, caller), nil
+}
+
+func (h *ChatHandler) Vote(ctx context.Context, req *pb.VoteRequest) (*pb.Poll, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("Vote request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("pollId", req.PollId),
+		zap.String("caller", caller),
+	)
+
+	optionIDs := make([]uint, len(req.OptionIds))
+	for i, id := range req.OptionIds {
+		optionIDs[i] = uint(id)
+	}
+
+	poll, err := h.Service.PollService.Vote(uint(req.RoomId), uint(req.PollId), caller, optionIDs)
+	if err != nil {
+		h.Logger.Error("Failed to vote", zap.Uint64("pollId", req.PollId), zap.Error(err))
+		return nil, serviceError(err, "poll")
+	}
+	return toPbPoll(*poll, caller), nil
+}
+
+func (h *ChatHandler) RetractVote(ctx context.Context, req *pb.PollRequest) (*pb.Poll, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("RetractVote request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("pollId", req.PollId),
+		zap.String("caller", caller),
+	)
+
+	poll, err := h.Service.PollService.RetractVote(uint(req.RoomId), uint(req.PollId), caller)
+	if err != nil {
+		h.Logger.Error("Failed to retract vote", zap.Uint64("pollId", req.PollId), zap.Error(err))
+		return nil, serviceError(err, "poll")
+	}
+	return toPbPoll(*poll, caller), nil
+}
+
+func (h *ChatHandler) ClosePoll(ctx context.Context, req *pb.PollRequest) (*pb.Poll, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ClosePoll request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("pollId", req.PollId),
+		zap.String("caller", caller),
+	)
+
+	poll, err := h.Service.PollService.ClosePoll(uint(req.RoomId), uint(req.PollId), caller)
+	if err != nil {
+		h.Logger.Error("Failed to close poll", zap.Uint64("pollId", req.PollId), zap.Error(err))
+		return nil, serviceError(err, "poll")
+	}
+	return toPbPoll(*poll, caller), nil
+}
+
+// toPbPoll converts a poll, with its options and votes preloaded, as seen by userEmail
+func toPbPoll(p model.Poll, userEmail string) *pb.Poll {
+	tally := p.Tally()
+
+	options := make([]*pb.PollOption, len(tally.Options))
+	for i, o := range tally.Options {
+		options[i] = &pb.PollOption{
+			OptionId: uint64(o.OptionId),
+			Text:     o.Text,
+			Votes:    uint32(o.Votes),
+			Voters:   o.Voters,
+		}
+	}
+
+	var myVotes []uint64
+	for _, id := range p.VotesOf(userEmail) {
+		myVotes = append(myVotes, uint64(id))
+	}
+
+	var closedAt string
+	if p.ClosedAt != nil {
+		closedAt = p.ClosedAt.String()
+	}
+
+	return &pb.Poll{
+		PollId:         uint64(p.ID),
+		RoomId:         uint64(p.RoomID),
+		MessageId:      uint64(p.MessageID),
+		Question:       p.Question,
+		MultipleChoice: p.MultipleChoice,
+		Anonymous:      p.Anonymous,
+		CreatedBy:      p.CreatedBy,
+		Closed:         p.ClosedAt != nil,
+		ClosedAt:       closedAt,
+		Options:        options,
+		TotalVoters:    uint32(tally.TotalVoters),
+		MyVotes:        myVotes,
+	}
+}
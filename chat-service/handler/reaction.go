@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+func (h *ChatHandler) AddReaction(ctx context.Context, req *pb.ReactionRequest) (*pb.ReactionResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("AddReaction request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("messageId", req.MessageId),
+		zap.String("caller", caller),
+	)
+
+	counts, err := h.Service.ChatService.AddReaction(uint(req.RoomId), uint(req.MessageId), caller, req.Emoji)
+	if err != nil {
+		h.Logger.Error("Failed to add reaction", zap.Uint64("messageId", req.MessageId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	return toPbReactionResponse(req, caller, counts), nil
+}
+
+func (h *ChatHandler) RemoveReaction(ctx context.Context, req *pb.ReactionRequest) (*pb.ReactionResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("RemoveReaction request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("messageId", req.MessageId),
+		zap.String("caller", caller),
+	)
+
+	counts, err := h.Service.ChatService.RemoveReaction(uint(req.RoomId), uint(req.MessageId), caller, req.Emoji)
+	if err != nil {
+		h.Logger.Error("Failed to remove reaction", zap.Uint64("messageId", req.MessageId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	return toPbReactionResponse(req, caller, counts), nil
+}
+
+func toPbReactionResponse(req *pb.ReactionRequest, caller string, counts []model.ReactionCount) *pb.ReactionResponse {
+	return &pb.ReactionResponse{
+		RoomId:    req.RoomId,
+		MessageId: req.MessageId,
+		UserEmail: caller,
+		Emoji:     req.Emoji,
+		Reactions: toPbReactionCounts(counts),
+	}
+}
+
+func toPbReactionCounts(counts []model.ReactionCount) []*pb.ReactionCount {
+	reactions := make([]*pb.ReactionCount, len(counts))
+	for i, c := range counts {
+		reactions[i] = &pb.ReactionCount{
+			Emoji: c.Emoji,
+			Count: uint32(c.Count),
+		}
+	}
+	return reactions
+}
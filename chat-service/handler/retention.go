@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+func (h *ChatHandler) SetRoomRetention(ctx context.Context, req *pb.SetRoomRetentionRequest) (*pb.SetRoomRetentionResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("SetRoomRetention request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.String("retention", req.Retention),
+	)
+
+	message, err := h.Service.RetentionService.SetRoomRetention(uint(req.RoomId), caller, req.Retention)
+	if err != nil {
+		h.Logger.Error("Failed to set room retention", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "room")
+	}
+
+	res := &pb.SetRoomRetentionResponse{
+		RoomId:    req.RoomId,
+		Retention: req.Retention,
+	}
+	if message != nil {
+		res.SystemMessage = toPbMessage(*message, 0, caller)
+	}
+	return res, nil
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// CallerEmailKey is the metadata key carrying the email of the authenticated
+// user on requests from the gateway
+const CallerEmailKey = "x-user-email"
+
+// previewRunes is the length of the last message preview in the room list
+const previewRunes = 80
+
+func (h *ChatHandler) ListUserRooms(ctx context.Context, req *pb.ListUserRoomsRequest) (*pb.ListUserRoomsResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ListUserRooms request", zap.String("caller", caller))
+
+	rooms, err := h.Service.ChatService.ListUserRooms(caller)
+	if err != nil {
+		h.Logger.Error("Error fetching user rooms", zap.String("caller", caller), zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to fetch rooms")
+	}
+
+	summaries := make([]*pb.RoomSummary, len(rooms))
+	for i, r := range rooms {
+		summary := &pb.RoomSummary{
+			RoomId:      uint64(r.RoomID),
+			RoomName:    r.RoomName,
+			UnreadCount: uint32(r.UnreadCount),
+		}
+		if r.LastMessageID != nil {
+			summary.LastMessageId = uint64(*r.LastMessageID)
+			summary.LastMessageSender = *r.LastMessageSender
+			summary.LastMessagePreview = preview(*r.LastMessageText)
+			summary.LastMessageAt = r.LastMessageAt.String()
+		}
+		summaries[i] = summary
+	}
+
+	return &pb.ListUserRoomsResponse{Rooms: summaries}, nil
+}
+
+// preview shortens content to previewRunes characters
+func preview(content string) string {
+	if utf8.RuneCountInString(content) <= previewRunes {
+		return content
+	}
+	return string([]rune(content)[:previewRunes]) + "…"
+}
+
+func (h *ChatHandler) RenameRoom(ctx context.Context, req *pb.RenameRoomRequest) (*pb.RenameRoomResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("RenameRoom request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.String("roomName", req.RoomName),
+	)
+
+	message, err := h.Service.ChatService.RenameRoom(uint(req.RoomId), caller, req.RoomName)
+	if err != nil {
+		h.Logger.Error("Failed to rename room", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "room")
+	}
+
+	room, err := h.Service.ChatService.GetRoomDetails(uint(req.RoomId))
+	if err != nil {
+		h.Logger.Error("Error fetching room details", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "room")
+	}
+
+	return &pb.RenameRoomResponse{
+		RoomId:        req.RoomId,
+		RoomName:      room.Name,
+		SystemMessage: toPbMessage(*message, 0, caller),
+	}, nil
+}
+
+func (h *ChatHandler) SetParticipantRole(ctx context.Context, req *pb.SetParticipantRoleRequest) (*pb.User, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("SetParticipantRole request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.String("userEmail", req.UserEmail),
+		zap.String("role", req.Role),
+	)
+
+	participant, err := h.Service.ChatService.SetParticipantRole(uint(req.RoomId), caller, req.UserEmail, req.Role)
+	if err != nil {
+		h.Logger.Error("Failed to set participant role", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "participant")
+	}
+	return toPbUser(*participant), nil
+}
+
+func (h *ChatHandler) RemoveRoomParticipant(ctx context.Context, req *pb.RoomMemberRequest) (*pb.RoomMembershipResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("RemoveRoomParticipant request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.String("userEmail", req.UserEmail),
+	)
+
+	message, err := h.Service.ChatService.RemoveParticipant(uint(req.RoomId), caller, req.UserEmail)
+	if err != nil {
+		h.Logger.Error("Failed to remove participant", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "participant")
+	}
+	return toPbMembership(req.RoomId, req.UserEmail, message, caller), nil
+}
+
+func (h *ChatHandler) LeaveRoom(ctx context.Context, req *pb.LeaveRoomRequest) (*pb.RoomMembershipResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("LeaveRoom request", zap.Uint64("roomId", req.RoomId), zap.String("caller", caller))
+
+	message, err := h.Service.ChatService.LeaveRoom(uint(req.RoomId), caller)
+	if err != nil {
+		h.Logger.Error("Failed to leave room", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "room")
+	}
+	return toPbMembership(req.RoomId, caller, message, caller), nil
+}
+
+func (h *ChatHandler) TransferOwnership(ctx context.Context, req *pb.RoomMemberRequest) (*pb.RoomMembershipResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("TransferOwnership request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+		zap.String("userEmail", req.UserEmail),
+	)
+
+	message, err := h.Service.ChatService.TransferOwnership(uint(req.RoomId), caller, req.UserEmail)
+	if err != nil {
+		h.Logger.Error("Failed to transfer ownership", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "participant")
+	}
+	return toPbMembership(req.RoomId, req.UserEmail, message, caller), nil
+}
+
+// callerEmail reads the authenticated user the gateway forwards in the request
+// metadata
+func callerEmail(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		if values := md.Get(CallerEmailKey); len(values) > 0 && values[0] != "" {
+			return values[0], nil
+		}
+	}
+	return "", status.Errorf(codes.Unauthenticated, "missing caller identity")
+}
+
+func toPbUser(p model.RoomParticipant) *pb.User {
+	return &pb.User{
+		UserId:   uint64(p.UserID),
+		Username: p.UserEmail,
+		Role:     p.Role,
+	}
+}
+
+func toPbUsers(participants []model.RoomParticipant) []*pb.User {
+	users := make([]*pb.User, len(participants))
+	for i, p := range participants {
+		users[i] = toPbUser(p)
+	}
+	return users
+}
+
+func toPbMembership(roomID uint64, userEmail string, message *model.Message, caller string) *pb.RoomMembershipResponse {
+	return &pb.RoomMembershipResponse{
+		RoomId:        roomID,
+		UserEmail:     userEmail,
+		SystemMessage: toPbMessage(*message, 0, caller),
+	}
+}
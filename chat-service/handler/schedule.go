@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/helper"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *ChatHandler) ScheduleMessage(ctx context.Context, req *pb.ScheduleMessageRequest) (*pb.ScheduledMessage, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ScheduleMessage request", zap.Uint64("roomId", req.RoomId), zap.String("sendAt", req.SendAt))
+
+	sendAt, err := time.Parse(time.RFC3339, req.SendAt)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid send_at: %v", err)
+	}
+
+	scheduled := &model.ScheduledMessage{
+		RoomID:      uint(req.RoomId),
+		SenderEmail: caller,
+		Content:     req.Content,
+		SendAt:      sendAt,
+	}
+	if req.AttachmentUrl != "" {
+		scheduled.AttachmentURL = helper.Ptr(req.AttachmentUrl)
+	}
+	if req.ReplyTo != 0 {
+		scheduled.ReplyTo = helper.Ptr(uint(req.ReplyTo))
+	}
+
+	if err := h.Service.ScheduleService.ScheduleMessage(scheduled); err != nil {
+		h.Logger.Error("Failed to schedule message", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "scheduled message")
+	}
+	return toPbScheduledMessage(scheduled), nil
+}
+
+func (h *ChatHandler) ListScheduled(ctx context.Context, req *pb.ListScheduledRequest) (*pb.ListScheduledResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ListScheduled request", zap.String("caller", caller), zap.Uint64("roomId", req.RoomId))
+
+	scheduled, err := h.Service.ScheduleService.ListScheduled(caller, uint(req.RoomId))
+	if err != nil {
+		h.Logger.Error("Error fetching scheduled messages", zap.String("caller", caller), zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to fetch scheduled messages")
+	}
+
+	res := &pb.ListScheduledResponse{Scheduled: make([]*pb.ScheduledMessage, len(scheduled))}
+	for i := range scheduled {
+		res.Scheduled[i] = toPbScheduledMessage(&scheduled[i])
+	}
+	return res, nil
+}
+
+func (h *ChatHandler) CancelScheduled(ctx context.Context, req *pb.CancelScheduledRequest) (*pb.ScheduledMessage, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("CancelScheduled request", zap.Uint64("scheduledId", req.ScheduledId))
+
+	scheduled, err := h.Service.ScheduleService.CancelScheduled(uint(req.ScheduledId), caller)
+	if err != nil {
+		h.Logger.Error("Failed to cancel scheduled message", zap.Uint64("scheduledId", req.ScheduledId), zap.Error(err))
+		return nil, serviceError(err, "scheduled message")
+	}
+	return toPbScheduledMessage(scheduled), nil
+}
+
+func toPbScheduledMessage(s *model.ScheduledMessage) *pb.ScheduledMessage {
+	scheduled := &pb.ScheduledMessage{
+		ScheduledId: uint64(s.ID),
+		RoomId:      uint64(s.RoomID),
+		SenderEmail: s.SenderEmail,
+		Content:     s.Content,
+		SendAt:      s.SendAt.UTC().Format(time.RFC3339),
+		Status:      s.Status,
+		Error:       s.Error,
+	}
+	if s.AttachmentURL != nil {
+		scheduled.AttachmentUrl = *s.AttachmentURL
+	}
+	if s.ReplyTo != nil {
+		scheduled.ReplyTo = uint64(*s.ReplyTo)
+	}
+	if s.MessageID != nil {
+		scheduled.MessageId = uint64(*s.MessageID)
+	}
+	return scheduled
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *ChatHandler) SearchMessages(ctx context.Context, req *pb.SearchMessagesRequest) (*pb.SearchMessagesResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("SearchMessages request",
+		zap.String("caller", caller),
+		zap.Uint64("roomId", req.RoomId),
+		zap.Int("limit", int(req.Limit)),
+		zap.Int("page", int(req.Page)),
+	)
+
+	search := model.MessageSearch{
+		UserEmail:   caller,
+		Query:       req.Query,
+		RoomID:      uint(req.RoomId),
+		SenderEmail: req.SenderEmail,
+	}
+
+	if search.From, err = parseSearchTime(req.From); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid from: %v", err)
+	}
+	if search.To, err = parseSearchTime(req.To); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid to: %v", err)
+	}
+
+	result, err := h.Service.ChatService.SearchMessages(search, int(req.Limit), int(req.Page))
+	if err != nil {
+		h.Logger.Error("Error searching messages", zap.String("caller", caller), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	recipients := make(roomRecipients)
+	hits := make([]*pb.SearchHit, 0, len(result.Messages))
+	for _, m := range result.Messages {
+		n, err := recipients.count(h, m.RoomID)
+		if err != nil {
+			return nil, err
+		}
+
+		hits = append(hits, &pb.SearchHit{
+			RoomId:    uint64(m.RoomID),
+			Message:   toPbMessage(m, n, caller),
+			Highlight: m.Highlight,
+		})
+	}
+
+	return &pb.SearchMessagesResponse{
+		Hits: hits,
+		Pagination: &pb.Pagination{
+			Page:       uint32(result.Page),
+			Limit:      uint32(result.Limit),
+			TotalPages: uint32(result.TotalPages),
+			TotalItems: uint32(result.TotalItems),
+		},
+	}, nil
+}
+
+// parseSearchTime parses an optional RFC 3339 timestamp
+func parseSearchTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// roomRecipients caches the number of recipients of each room, which the status
+// of messages from several rooms depends on
+type roomRecipients map[uint]int
+
+func (r roomRecipients) count(h *ChatHandler, roomID uint) (int, error) {
+	if n, ok := r[roomID]; ok {
+		return n, nil
+	}
+	participants, err := h.Service.ChatService.GetRoomParticipants(roomID)
+	if err != nil {
+		h.Logger.Error("Error fetching room participants", zap.Uint("roomId", roomID), zap.Error(err))
+		return 0, err
+	}
+	r[roomID] = len(participants) - 1
+	return r[roomID], nil
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+func (h *ChatHandler) GetThread(ctx context.Context, req *pb.GetThreadRequest) (*pb.ThreadResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("GetThread request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("messageId", req.MessageId),
+		zap.Int("limit", int(req.Limit)),
+		zap.Int("page", int(req.Page)),
+	)
+
+	root, replies, err := h.Service.ChatService.GetThread(uint(req.RoomId), uint(req.MessageId), caller, int(req.Limit), int(req.Page))
+	if err != nil {
+		h.Logger.Error("Error fetching thread", zap.Uint64("messageId", req.MessageId), zap.Error(err))
+		return nil, serviceError(err, "message")
+	}
+
+	participants, err := h.Service.ChatService.GetRoomParticipants(uint(req.RoomId))
+	if err != nil {
+		h.Logger.Error("Error fetching room participants", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, err
+	}
+	recipients := len(participants) - 1
+
+	msgs := make([]*pb.Message, 0, len(replies.Messages))
+	for _, m := range replies.Messages {
+		msgs = append(msgs, toPbMessage(m, recipients, caller))
+	}
+
+	return &pb.ThreadResponse{
+		RoomId:  req.RoomId,
+		Root:    toPbMessage(*root, recipients, caller),
+		Replies: msgs,
+		Pagination: &pb.Pagination{
+			Page:       uint32(replies.Page),
+			Limit:      uint32(replies.Limit),
+			TotalPages: uint32(replies.TotalPages),
+			TotalItems: uint32(replies.TotalItems),
+		},
+	}, nil
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"project/chat-service/model"
+	pb "project/chat-service/proto"
+
+	"go.uber.org/zap"
+)
+
+func (h *ChatHandler) CreateWebhook(ctx context.Context, req *pb.CreateWebhookRequest) (*pb.Webhook, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("CreateWebhook request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+	)
+
+	webhook, err := h.Service.WebhookService.CreateWebhook(uint(req.RoomId), caller, req.Url)
+	if err != nil {
+		h.Logger.Error("Failed to create webhook", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "webhook")
+	}
+
+	res := toPbWebhook(*webhook)
+	res.Secret = webhook.Secret
+	return res, nil
+}
+
+func (h *ChatHandler) ListWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ListWebhooks request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.String("caller", caller),
+	)
+
+	webhooks, err := h.Service.WebhookService.ListWebhooks(uint(req.RoomId), caller)
+	if err != nil {
+		h.Logger.Error("Error fetching webhooks", zap.Uint64("roomId", req.RoomId), zap.Error(err))
+		return nil, serviceError(err, "webhook")
+	}
+
+	res := make([]*pb.Webhook, len(webhooks))
+	for i, w := range webhooks {
+		res[i] = toPbWebhook(w)
+	}
+	return &pb.ListWebhooksResponse{Webhooks: res}, nil
+}
+
+func (h *ChatHandler) DeleteWebhook(ctx context.Context, req *pb.WebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("DeleteWebhook request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("webhookId", req.WebhookId),
+		zap.String("caller", caller),
+	)
+
+	if err := h.Service.WebhookService.DeleteWebhook(uint(req.RoomId), uint(req.WebhookId), caller); err != nil {
+		h.Logger.Error("Failed to delete webhook", zap.Uint64("webhookId", req.WebhookId), zap.Error(err))
+		return nil, serviceError(err, "webhook")
+	}
+	return &pb.DeleteWebhookResponse{WebhookId: req.WebhookId}, nil
+}
+
+func (h *ChatHandler) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	caller, err := callerEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.Logger.Info("ListWebhookDeliveries request",
+		zap.Uint64("roomId", req.RoomId),
+		zap.Uint64("webhookId", req.WebhookId),
+		zap.Int("limit", int(req.Limit)),
+		zap.Int("page", int(req.Page)),
+	)
+
+	result, err := h.Service.WebhookService.ListDeliveries(uint(req.RoomId), uint(req.WebhookId), caller, int(req.Limit), int(req.Page))
+	if err != nil {
+		h.Logger.Error("Error fetching webhook deliveries", zap.Uint64("webhookId", req.WebhookId), zap.Error(err))
+		return nil, serviceError(err, "webhook")
+	}
+
+	deliveries := make([]*pb.WebhookDelivery, len(result.Deliveries))
+	for i, d := range result.Deliveries {
+		deliveries[i] = &pb.WebhookDelivery{
+			DeliveryId:     uint64(d.ID),
+			Event:          d.Event,
+			Status:         d.Status,
+			Attempts:       uint32(d.Attempts),
+			LastStatusCode: uint32(d.LastStatusCode),
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt.UTC().String(),
+		}
+		if d.NextAttemptAt != nil {
+			deliveries[i].NextAttemptAt = d.NextAttemptAt.UTC().String()
+		}
+		if d.DeliveredAt != nil {
+			deliveries[i].DeliveredAt = d.DeliveredAt.UTC().String()
+		}
+	}
+
+	return &pb.ListWebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		Pagination: &pb.Pagination{
+			Page:       uint32(result.Page),
+			Limit:      uint32(result.Limit),
+			TotalPages: uint32(result.TotalPages),
+			TotalItems: uint32(result.TotalItems),
+		},
+	}, nil
+}
+
+// toPbWebhook converts a webhook without its secret
+func toPbWebhook(webhook model.RoomWebhook) *pb.Webhook {
+	return &pb.Webhook{
+		WebhookId: uint64(webhook.ID),
+		RoomId:    uint64(webhook.RoomID),
+		Url:       webhook.URL,
+		CreatedBy: webhook.CreatedBy,
+		CreatedAt: webhook.CreatedAt.UTC().String(),
+	}
+}
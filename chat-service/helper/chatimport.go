@@ -0,0 +1,288 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportedMessage is a message read from another messenger's export
+type ImportedMessage struct {
+	Line     int    // Where the message starts, see ParseError
+	SourceID string // Id in the export, empty when it has none
+	Sender   string // Display name in the export
+	Content  string
+	SentAt   time.Time
+	ReplyTo  string // SourceID of the message answered, if any
+}
+
+// ParseError is a part of an export that could not be read. Line is the line
+// number in WhatsApp exports and the position in the messages array of
+// Telegram exports, both starting at 1.
+type ParseError struct {
+	Line   int
+	Reason string
+}
+
+// ParsedChat is the content of an export
+type ParsedChat struct {
+	Title    string // Name of the chat, when the export has one
+	Messages []ImportedMessage
+	Errors   []ParseError
+	Skipped  int // Service notices such as "X joined", which have no sender
+}
+
+// whatsAppLine matches the start of a message in both Android exports,
+// "31/12/20, 21:41 - Alice: Hi", and iOS ones, "[31/12/2020, 21:41:05] Alice: Hi"
+var whatsAppLine = regexp.MustCompile(`^\[?(\d{1,2})[./-](\d{1,2})[./-](\d{2}|\d{4}),?\s+(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?\s*([AaPp])?\.?\s*(?:[Mm]\.?)?\]?\s*(?:-\s+)?(.*)$`)
+
+// whatsAppCleaner drops the direction marks and odd spaces newer exports contain
+var whatsAppCleaner = strings.NewReplacer("\u200e", "", "\u200f", "", "\ufeff", "", "\u202f", " ", "\u00a0", " ")
+
+type whatsAppEntry struct {
+	line   int
+	fields []string
+	text   []string
+}
+
+// ParseWhatsApp reads a WhatsApp .txt export. Its timestamps have no zone and
+// are read in loc. Lines without a timestamp continue the previous message.
+func ParseWhatsApp(data []byte, loc *time.Location) ParsedChat {
+	var chat ParsedChat
+	var entries []*whatsAppEntry
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := whatsAppCleaner.Replace(strings.TrimRight(scanner.Text(), "\r"))
+		if fields := whatsAppLine.FindStringSubmatch(text); fields != nil {
+			entries = append(entries, &whatsAppEntry{line: line, fields: fields})
+			continue
+		}
+		if len(entries) == 0 {
+			if strings.TrimSpace(text) != "" {
+				chat.Errors = append(chat.Errors, ParseError{Line: line, Reason: "line does not start with a timestamp"})
+			}
+			continue
+		}
+		last := entries[len(entries)-1]
+		last.text = append(last.text, text)
+	}
+	if err := scanner.Err(); err != nil {
+		chat.Errors = append(chat.Errors, ParseError{Line: line + 1, Reason: err.Error()})
+	}
+
+	dayFirst := whatsAppDayFirst(entries)
+	for _, e := range entries {
+		sentAt, err := whatsAppTime(e.fields, dayFirst, loc)
+		if err != nil {
+			chat.Errors = append(chat.Errors, ParseError{Line: e.line, Reason: err.Error()})
+			continue
+		}
+
+		// notices have no "sender: " prefix
+		sender, content, ok := strings.Cut(e.fields[8], ": ")
+		if !ok || strings.TrimSpace(sender) == "" {
+			chat.Skipped++
+			continue
+		}
+		content = strings.Join(append([]string{content}, e.text...), "\n")
+		chat.Messages = append(chat.Messages, ImportedMessage{
+			Line:    e.line,
+			Sender:  strings.TrimSpace(sender),
+			Content: strings.TrimSpace(content),
+			SentAt:  sentAt,
+		})
+	}
+	return chat
+}
+
+// whatsAppDayFirst tells 31/12 from 12/31 dates. Exports use a single order,
+// so one field above 12 settles it; otherwise 12 hour clocks go with months first.
+func whatsAppDayFirst(entries []*whatsAppEntry) bool {
+	twelveHour := false
+	for _, e := range entries {
+		first, _ := strconv.Atoi(e.fields[1])
+		second, _ := strconv.Atoi(e.fields[2])
+		if first > 12 {
+			return true
+		}
+		if second > 12 {
+			return false
+		}
+		twelveHour = twelveHour || e.fields[7] != ""
+	}
+	return !twelveHour
+}
+
+func whatsAppTime(fields []string, dayFirst bool, loc *time.Location) (time.Time, error) {
+	day, _ := strconv.Atoi(fields[1])
+	month, _ := strconv.Atoi(fields[2])
+	if !dayFirst {
+		day, month = month, day
+	}
+	year, _ := strconv.Atoi(fields[3])
+	if year < 100 {
+		year += 2000
+	}
+	hour, _ := strconv.Atoi(fields[4])
+	minute, _ := strconv.Atoi(fields[5])
+	second := 0
+	if fields[6] != "" {
+		second, _ = strconv.Atoi(fields[6])
+	}
+	switch strings.ToLower(fields[7]) {
+	case "a":
+		if hour == 12 {
+			hour = 0
+		}
+	case "p":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+
+	t := time.Date(year, time.Month(month), day, hour, minute, second, 0, loc)
+	// time.Date normalizes out of range values, a round trip catches them
+	if t.Day() != day || int(t.Month()) != month || t.Hour() != hour || t.Minute() != minute || t.Second() != second {
+		return time.Time{}, fmt.Errorf("invalid date %s/%s/%s %s:%s", fields[1], fields[2], fields[3], fields[4], fields[5])
+	}
+	return t, nil
+}
+
+type telegramExport struct {
+	Name     string            `json:"name"`
+	Messages []json.RawMessage `json:"messages"`
+}
+
+type telegramMessage struct {
+	ID               int64           `json:"id"`
+	Type             string          `json:"type"`
+	Date             string          `json:"date"`
+	DateUnixtime     string          `json:"date_unixtime"`
+	From             *string         `json:"from"`
+	Text             json.RawMessage `json:"text"`
+	ReplyToMessageID int64           `json:"reply_to_message_id"`
+	Photo            string          `json:"photo"`
+	File             string          `json:"file"`
+}
+
+// telegramDeletedSender is how Telegram shows the author of a deleted account
+const telegramDeletedSender = "Deleted Account"
+
+// ParseTelegram reads the result.json of a Telegram chat export. Dates without
+// a unix time are read in loc.
+func ParseTelegram(data []byte, loc *time.Location) (ParsedChat, error) {
+	var export telegramExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return ParsedChat{}, fmt.Errorf("not a Telegram export: %w", err)
+	}
+
+	chat := ParsedChat{Title: export.Name}
+	for i, raw := range export.Messages {
+		position := i + 1
+
+		var m telegramMessage
+		if err := json.Unmarshal(raw, &m); err != nil {
+			chat.Errors = append(chat.Errors, ParseError{Line: position, Reason: err.Error()})
+			continue
+		}
+		if m.Type != "message" {
+			chat.Skipped++
+			continue
+		}
+
+		sentAt, err := telegramTime(m, loc)
+		if err != nil {
+			chat.Errors = append(chat.Errors, ParseError{Line: position, Reason: err.Error()})
+			continue
+		}
+		text, err := telegramText(m.Text)
+		if err != nil {
+			chat.Errors = append(chat.Errors, ParseError{Line: position, Reason: err.Error()})
+			continue
+		}
+		lines := make([]string, 0, 3)
+		if text != "" {
+			lines = append(lines, text)
+		}
+		if m.Photo != "" {
+			lines = append(lines, "[photo: "+m.Photo+"]")
+		}
+		if m.File != "" {
+			lines = append(lines, "[file: "+m.File+"]")
+		}
+
+		sender := telegramDeletedSender
+		if m.From != nil && strings.TrimSpace(*m.From) != "" {
+			sender = strings.TrimSpace(*m.From)
+		}
+		imported := ImportedMessage{
+			Line:     position,
+			SourceID: strconv.FormatInt(m.ID, 10),
+			Sender:   sender,
+			Content:  strings.Join(lines, "\n"),
+			SentAt:   sentAt,
+		}
+		if m.ReplyToMessageID != 0 {
+			imported.ReplyTo = strconv.FormatInt(m.ReplyToMessageID, 10)
+		}
+		chat.Messages = append(chat.Messages, imported)
+	}
+	return chat, nil
+}
+
+func telegramTime(m telegramMessage, loc *time.Location) (time.Time, error) {
+	if m.DateUnixtime != "" {
+		seconds, err := strconv.ParseInt(m.DateUnixtime, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date_unixtime %q", m.DateUnixtime)
+		}
+		return time.Unix(seconds, 0), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02T15:04:05", m.Date, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", m.Date)
+	}
+	return t, nil
+}
+
+// telegramText flattens a text, which is either a string or an array of
+// strings and formatted entities
+func telegramText(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", fmt.Errorf("text is neither a string nor an array")
+	}
+	var b strings.Builder
+	for _, part := range parts {
+		var s string
+		if err := json.Unmarshal(part, &s); err == nil {
+			b.WriteString(s)
+			continue
+		}
+		var entity struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(part, &entity); err != nil {
+			return "", fmt.Errorf("invalid text entity")
+		}
+		b.WriteString(entity.Text)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,200 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWhatsApp(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	tests := []struct {
+		name        string
+		export      string
+		want        []ImportedMessage
+		wantErrors  []ParseError
+		wantSkipped int
+	}{
+		{
+			name: "android day first",
+			export: "31/12/20, 21:41 - Messages and calls are end-to-end encrypted.\n" +
+				"31/12/20, 21:41 - Alice: Happy new year\n" +
+				"01/01/21, 00:02 - Bob: Same to you\n",
+			want: []ImportedMessage{
+				{Line: 2, Sender: "Alice", Content: "Happy new year", SentAt: time.Date(2020, 12, 31, 21, 41, 0, 0, jakarta)},
+				{Line: 3, Sender: "Bob", Content: "Same to you", SentAt: time.Date(2021, 1, 1, 0, 2, 0, 0, jakarta)},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name: "ios with seconds and direction marks",
+			export: "\ufeff[31/12/2020, 21:41:05] Alice: Hi\r\n" +
+				"\u200e[31/12/2020, 21:42:00] Bob: \u200eimage omitted\r\n",
+			want: []ImportedMessage{
+				{Line: 1, Sender: "Alice", Content: "Hi", SentAt: time.Date(2020, 12, 31, 21, 41, 5, 0, jakarta)},
+				{Line: 2, Sender: "Bob", Content: "image omitted", SentAt: time.Date(2020, 12, 31, 21, 42, 0, 0, jakarta)},
+			},
+		},
+		{
+			name: "twelve hour clock months first",
+			export: "1/2/21, 12:05 AM - Alice: Midnight\n" +
+				"1/2/21, 3:30 PM - Bob: Afternoon\n",
+			want: []ImportedMessage{
+				{Line: 1, Sender: "Alice", Content: "Midnight", SentAt: time.Date(2021, 1, 2, 0, 5, 0, 0, jakarta)},
+				{Line: 2, Sender: "Bob", Content: "Afternoon", SentAt: time.Date(2021, 1, 2, 15, 30, 0, 0, jakarta)},
+			},
+		},
+		{
+			name: "months first settled by a later date",
+			export: "01/02/21, 09:00 - Alice: First\n" +
+				"01/13/21, 09:00 - Bob: Second\n",
+			want: []ImportedMessage{
+				{Line: 1, Sender: "Alice", Content: "First", SentAt: time.Date(2021, 1, 2, 9, 0, 0, 0, jakarta)},
+				{Line: 2, Sender: "Bob", Content: "Second", SentAt: time.Date(2021, 1, 13, 9, 0, 0, 0, jakarta)},
+			},
+		},
+		{
+			name: "multiline message",
+			export: "05/06/22, 10:00 - Alice: Shopping list:\n" +
+				"eggs\n" +
+				"milk: 2 litres\n" +
+				"05/06/22, 10:01 - Bob: Ok\n",
+			want: []ImportedMessage{
+				{Line: 1, Sender: "Alice", Content: "Shopping list:\neggs\nmilk: 2 litres", SentAt: time.Date(2022, 6, 5, 10, 0, 0, 0, jakarta)},
+				{Line: 4, Sender: "Bob", Content: "Ok", SentAt: time.Date(2022, 6, 5, 10, 1, 0, 0, jakarta)},
+			},
+		},
+		{
+			name: "errors keep their line",
+			export: "exported from my phone\n" +
+				"\n" +
+				"31/02/21, 10:00 - Alice: Not a day\n" +
+				"28/02/21, 10:00 - Alice: Fine\n",
+			want: []ImportedMessage{
+				{Line: 4, Sender: "Alice", Content: "Fine", SentAt: time.Date(2021, 2, 28, 10, 0, 0, 0, jakarta)},
+			},
+			wantErrors: []ParseError{
+				{Line: 1, Reason: "line does not start with a timestamp"},
+				{Line: 3, Reason: "invalid date 31/02/21 10:00"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := ParseWhatsApp([]byte(tt.export), jakarta)
+			assertMessages(t, chat.Messages, tt.want)
+			if !reflect.DeepEqual(chat.Errors, tt.wantErrors) {
+				t.Errorf("Errors = %+v, want %+v", chat.Errors, tt.wantErrors)
+			}
+			if chat.Skipped != tt.wantSkipped {
+				t.Errorf("Skipped = %d, want %d", chat.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestParseTelegram(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	tests := []struct {
+		name        string
+		export      string
+		wantTitle   string
+		want        []ImportedMessage
+		wantErrors  []ParseError
+		wantSkipped int
+		wantErr     bool
+	}{
+		{
+			name: "messages and service notices",
+			export: `{"name":"Team","messages":[
+				{"id":1,"type":"service","date":"2023-04-01T09:00:00","action":"create_group"},
+				{"id":2,"type":"message","date":"2023-04-01T09:01:00","date_unixtime":"1680314460","from":"Alice","text":"Morning"},
+				{"id":3,"type":"message","date":"2023-04-01T09:02:00","from":"Bob","text":"Hi","reply_to_message_id":2}
+			]}`,
+			wantTitle: "Team",
+			want: []ImportedMessage{
+				{Line: 2, SourceID: "2", Sender: "Alice", Content: "Morning", SentAt: time.Unix(1680314460, 0)},
+				{Line: 3, SourceID: "3", Sender: "Bob", Content: "Hi", SentAt: time.Date(2023, 4, 1, 9, 2, 0, 0, jakarta), ReplyTo: "2"},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name: "formatted text, media and deleted senders",
+			export: `{"messages":[
+				{"id":7,"type":"message","date":"2023-04-01T10:00:00","from":null,"text":["See ",{"type":"link","text":"example.com"}," now"]},
+				{"id":8,"type":"message","date":"2023-04-01T10:01:00","from":" Carol ","text":"","photo":"photos/1.jpg"},
+				{"id":9,"type":"message","date":"2023-04-01T10:02:00","from":"Carol","text":"report","file":"files/r.pdf"}
+			]}`,
+			want: []ImportedMessage{
+				{Line: 1, SourceID: "7", Sender: "Deleted Account", Content: "See example.com now", SentAt: time.Date(2023, 4, 1, 10, 0, 0, 0, jakarta)},
+				{Line: 2, SourceID: "8", Sender: "Carol", Content: "[photo: photos/1.jpg]", SentAt: time.Date(2023, 4, 1, 10, 1, 0, 0, jakarta)},
+				{Line: 3, SourceID: "9", Sender: "Carol", Content: "report\n[file: files/r.pdf]", SentAt: time.Date(2023, 4, 1, 10, 2, 0, 0, jakarta)},
+			},
+		},
+		{
+			name: "errors keep their position",
+			export: `{"messages":[
+				{"id":2,"type":"message","date":"yesterday","from":"Alice","text":"Hi"},
+				{"id":3,"type":"message","date":"2023-04-01T10:00:00","from":"Alice","text":42},
+				{"id":4,"type":"message","date":"2023-04-01T10:00:00","date_unixtime":"soon","from":"Alice","text":"Hi"}
+			]}`,
+			wantErrors: []ParseError{
+				{Line: 1, Reason: `invalid date "yesterday"`},
+				{Line: 2, Reason: "text is neither a string nor an array"},
+				{Line: 3, Reason: `invalid date_unixtime "soon"`},
+			},
+		},
+		{
+			name:    "not json",
+			export:  "31/12/20, 21:41 - Alice: Hi",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, err := ParseTelegram([]byte(tt.export), jakarta)
+			if tt.wantErr {
+				if err == nil || !strings.HasPrefix(err.Error(), "not a Telegram export") {
+					t.Fatalf("ParseTelegram() error = %v, want not a Telegram export", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTelegram() error = %v", err)
+			}
+			if chat.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", chat.Title, tt.wantTitle)
+			}
+			assertMessages(t, chat.Messages, tt.want)
+			if !reflect.DeepEqual(chat.Errors, tt.wantErrors) {
+				t.Errorf("Errors = %+v, want %+v", chat.Errors, tt.wantErrors)
+			}
+			if chat.Skipped != tt.wantSkipped {
+				t.Errorf("Skipped = %d, want %d", chat.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+// assertMessages compares imported messages, times by instant
+func assertMessages(t *testing.T, got, want []ImportedMessage) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d messages, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.SentAt.Equal(w.SentAt) {
+			t.Errorf("message %d SentAt = %v, want %v", i, g.SentAt, w.SentAt)
+		}
+		g.SentAt, w.SentAt = time.Time{}, time.Time{}
+		if g != w {
+			t.Errorf("message %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
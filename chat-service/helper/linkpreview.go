@@ -0,0 +1,209 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+var ErrNotHTML = errors.New("response is not an html page")
+
+// maxPreviewRedirects bounds the redirects followed to reach a page
+const maxPreviewRedirects = 3
+
+// LinkMetadata is what a page tells about itself through OpenGraph tags, then
+// Twitter card tags, or its <title> and description when it has neither
+type LinkMetadata struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"imageUrl,omitempty"`
+	SiteName    string `json:"siteName,omitempty"`
+}
+
+// Empty reports whether the page had nothing worth previewing
+func (m *LinkMetadata) Empty() bool {
+	return m.Title == "" && m.Description == "" && m.ImageURL == ""
+}
+
+// LinkFetcher downloads pages to preview them, from public addresses only
+type LinkFetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+// NewLinkFetcher returns a fetcher giving up after timeout and reading at most
+// maxBytes of a page. allowPrivate lifts the address check, for tests against a
+// local server only.
+func NewLinkFetcher(timeout time.Duration, maxBytes int64, allowPrivate bool) *LinkFetcher {
+	client := NewPublicHTTPClient(timeout, allowPrivate)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxPreviewRedirects {
+			return errors.New("too many redirects")
+		}
+		return checkPreviewURL(req.URL)
+	}
+	return &LinkFetcher{
+		client:   client,
+		maxBytes: maxBytes,
+	}
+}
+
+// Fetch reads the metadata of the page at rawURL
+func (f *LinkFetcher) Fetch(ctx context.Context, rawURL string) (*LinkMetadata, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPreviewURL(target); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "chat-service-link-preview/1.0")
+	req.Header.Set("Accept", "text/html")
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNotHTML
+	}
+
+	metadata := parseLinkMetadata(io.LimitReader(res.Body, f.maxBytes), res.Request.URL)
+	metadata.URL = rawURL
+	return metadata, nil
+}
+
+func checkPreviewURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q", ErrBlockedAddress, u.Scheme)
+	}
+	if u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, u.Redacted())
+	}
+	return nil
+}
+
+// parseLinkMetadata reads the head of a page, a truncated page yields whatever
+// was found before the cut
+func parseLinkMetadata(body io.Reader, base *url.URL) *LinkMetadata {
+	metadata := &LinkMetadata{}
+	twitter := &LinkMetadata{}
+	var title, description string
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(body)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return finishLinkMetadata(metadata, twitter, title, description, base)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = title == ""
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				key, content := metaAttributes(tokenizer)
+				switch key {
+				case "og:title":
+					metadata.Title = content
+				case "og:description":
+					metadata.Description = content
+				case "og:image", "og:image:url":
+					if metadata.ImageURL == "" {
+						metadata.ImageURL = content
+					}
+				case "og:site_name":
+					metadata.SiteName = content
+				case "twitter:title":
+					twitter.Title = content
+				case "twitter:description":
+					twitter.Description = content
+				case "twitter:image", "twitter:image:src":
+					if twitter.ImageURL == "" {
+						twitter.ImageURL = content
+					}
+				case "description":
+					description = content
+				}
+			case "body":
+				// metadata only lives in the head
+				return finishLinkMetadata(metadata, twitter, title, description, base)
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "title" {
+				inTitle = false
+			}
+		}
+	}
+}
+
+func metaAttributes(tokenizer *html.Tokenizer) (key, content string) {
+	for {
+		name, value, more := tokenizer.TagAttr()
+		switch strings.ToLower(string(name)) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(string(value)))
+			}
+		case "content":
+			content = strings.TrimSpace(string(value))
+		}
+		if !more {
+			return key, content
+		}
+	}
+}
+
+func finishLinkMetadata(metadata, twitter *LinkMetadata, title, description string, base *url.URL) *LinkMetadata {
+	if metadata.Title == "" {
+		metadata.Title = twitter.Title
+	}
+	if metadata.Title == "" {
+		metadata.Title = strings.Join(strings.Fields(title), " ")
+	}
+	if metadata.Description == "" {
+		metadata.Description = twitter.Description
+	}
+	if metadata.Description == "" {
+		metadata.Description = description
+	}
+	if metadata.ImageURL == "" {
+		metadata.ImageURL = twitter.ImageURL
+	}
+	if metadata.ImageURL != "" {
+		image, err := base.Parse(metadata.ImageURL)
+		if err != nil || (image.Scheme != "http" && image.Scheme != "https") {
+			metadata.ImageURL = ""
+		} else {
+			metadata.ImageURL = image.String()
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,248 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLinkMetadata(t *testing.T) {
+	base, _ := url.Parse("https://example.com/articles/1")
+
+	tests := []struct {
+		name string
+		page string
+		want LinkMetadata
+	}{
+		{
+			name: "opengraph",
+			page: `<html><head>
+				<title>Ignored title</title>
+				<meta property="og:title" content="OG title">
+				<meta property="og:description" content="OG description">
+				<meta property="og:image" content="/images/cover.png">
+				<meta property="og:site_name" content="Example">
+				<meta name="twitter:title" content="Twitter title">
+			</head></html>`,
+			want: LinkMetadata{
+				Title:       "OG title",
+				Description: "OG description",
+				ImageURL:    "https://example.com/images/cover.png",
+				SiteName:    "Example",
+			},
+		},
+		{
+			name: "twitter card",
+			page: `<html><head>
+				<title>Ignored title</title>
+				<meta name="description" content="Ignored description">
+				<meta name="twitter:title" content="Twitter title">
+				<meta name="twitter:description" content="Twitter description">
+				<meta name="twitter:image" content="https://cdn.example.com/card.jpg">
+			</head></html>`,
+			want: LinkMetadata{
+				Title:       "Twitter title",
+				Description: "Twitter description",
+				ImageURL:    "https://cdn.example.com/card.jpg",
+			},
+		},
+		{
+			name: "title fallback",
+			page: `<html><head>
+				<title>
+					Plain   page
+				</title>
+				<meta name="description" content="Plain description">
+			</head></html>`,
+			want: LinkMetadata{
+				Title:       "Plain page",
+				Description: "Plain description",
+			},
+		},
+		{
+			name: "stops at body",
+			page: `<html><head><title>Head title</title></head>
+				<body><meta property="og:title" content="Body title"></body></html>`,
+			want: LinkMetadata{
+				Title: "Head title",
+			},
+		},
+		{
+			name: "drops non http image",
+			page: `<html><head><meta property="og:image" content="javascript:alert(1)"></head></html>`,
+			want: LinkMetadata{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseLinkMetadata(strings.NewReader(tt.page), base)
+			if *got != tt.want {
+				t.Errorf("parseLinkMetadata() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkFetcherFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<html><head><meta property="og:title" content="Page"></head></html>`)
+		case "/redirect":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			fmt.Fprint(w, "png")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewLinkFetcher(time.Second, 1<<16, true)
+
+	metadata, err := fetcher.Fetch(context.Background(), server.URL+"/redirect")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if metadata.Title != "Page" || metadata.URL != server.URL+"/redirect" {
+		t.Errorf("Fetch() = %+v", *metadata)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/image"); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("Fetch() of an image error = %v, want %v", err, ErrNotHTML)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/missing"); err == nil {
+		t.Error("Fetch() of a missing page succeeded")
+	}
+	if _, err := fetcher.Fetch(context.Background(), "file:///etc/passwd"); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Fetch() of a file url error = %v, want %v", err, ErrBlockedAddress)
+	}
+}
+
+func TestLinkFetcherTruncatesPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><meta property="og:title" content="Kept">`)
+		fmt.Fprintf(w, "<!-- %s -->", strings.Repeat("x", 4096))
+		fmt.Fprint(w, `<meta property="og:description" content="Cut"></head></html>`)
+	}))
+	defer server.Close()
+
+	metadata, err := NewLinkFetcher(time.Second, 1024, true).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if metadata.Title != "Kept" {
+		t.Errorf("Title = %q, want %q", metadata.Title, "Kept")
+	}
+	if metadata.Description != "" {
+		t.Errorf("Description = %q, want it cut past the size limit", metadata.Description)
+	}
+}
+
+// publicHostTransport serves requests to a made up public host from a local
+// server and hands every other request to the fetcher's own transport
+type publicHostTransport struct {
+	host   string
+	server *httptest.Server
+	next   http.RoundTripper
+}
+
+func (t *publicHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.next.RoundTrip(req)
+	}
+	local, _ := url.Parse(t.server.URL)
+	req = req.Clone(req.Context())
+	req.URL.Scheme = local.Scheme
+	req.URL.Host = local.Host
+	return t.server.Client().Transport.RoundTrip(req)
+}
+
+func TestLinkFetcherRejectsRedirectToPrivateAddress(t *testing.T) {
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the private server was reached")
+	}))
+	defer private.Close()
+
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, private.URL+"/admin", http.StatusFound)
+	}))
+	defer public.Close()
+
+	fetcher := NewLinkFetcher(time.Second, 1<<16, false)
+	fetcher.client.Transport = &publicHostTransport{
+		host:   "public.example.com",
+		server: public,
+		next:   fetcher.client.Transport,
+	}
+
+	_, err := fetcher.Fetch(context.Background(), "http://public.example.com/")
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Fetch() error = %v, want %v", err, ErrBlockedAddress)
+	}
+}
+
+func TestPublicHTTPClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the local server was reached")
+	}))
+	defer server.Close()
+
+	client := NewPublicHTTPClient(time.Second, false)
+	for _, target := range []string{
+		server.URL,
+		"http://localhost/",
+		"http://10.0.0.1/",
+		"http://172.16.0.1/",
+		"http://192.168.1.1/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/",
+	} {
+		res, err := client.Get(target)
+		if err == nil {
+			res.Body.Close()
+		}
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("Get(%q) error = %v, want %v", target, err, ErrBlockedAddress)
+		}
+	}
+}
+
+func TestBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.31.255.255", true},
+		{"192.168.0.10", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"64:ff9b::a00:1", true},
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"2606:4700:4700::1111", false},
+	}
+
+	for _, tt := range tests {
+		if got := blockedIP(net.ParseIP(tt.ip)); got != tt.blocked {
+			t.Errorf("blockedIP(%s) = %v, want %v", tt.ip, got, tt.blocked)
+		}
+	}
+}
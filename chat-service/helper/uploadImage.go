@@ -75,3 +75,29 @@ func UploadFileThirdPartyAPI(file io.Reader, filename string) (string, error) {
 
 	return imageUrl, nil
 }
+
+// DeleteFileThirdPartyAPI asks the file API at deleteURL to remove an uploaded file
+func DeleteFileThirdPartyAPI(deleteURL, fileURL string) error {
+	body, err := json.Marshal(map[string]string{"url": fileURL})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, deleteURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete from third party, status: %s", resp.Status)
+	}
+	return nil
+}
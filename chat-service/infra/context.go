@@ -48,7 +48,7 @@ func NewServiceContext() (*ServiceContext, error) {
 	repo := repository.NewRepository(db, logger)
 
 	// instance service
-	services := service.NewService(repo, logger)
+	services := service.NewService(repo, appConfig, &rdb, logger)
 
 	// instance controller
 	Ctl := handler.NewHandler(services, logger)
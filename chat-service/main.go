@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
+	"os/signal"
 	"project/chat-service/handler"
 	"project/chat-service/infra"
+	"project/chat-service/service"
+	"sync"
+	"syscall"
+	"time"
 
 	pb "project/chat-service/proto"
 
@@ -25,14 +31,45 @@ func main() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	server := grpc.NewServer()
+	// SIGINT or SIGTERM stops the background jobs and the server
+	appContext, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	services := ctx.Ctl.ChatHandler.Service
+	var jobs sync.WaitGroup
+	jobs.Add(3)
+	go func() {
+		defer jobs.Done()
+		service.RunScheduleDispatcher(appContext, services.ScheduleService, time.Duration(ctx.Cfg.ScheduleInterval)*time.Second, ctx.Log)
+	}()
+	go func() {
+		defer jobs.Done()
+		service.RunRetentionSweeper(appContext, services.RetentionService, time.Duration(ctx.Cfg.SweepInterval)*time.Second, ctx.Log)
+	}()
+	go func() {
+		defer jobs.Done()
+		service.RunWebhookDispatcher(appContext, services.WebhookService, time.Duration(ctx.Cfg.WebhookInterval)*time.Second, ctx.Log)
+	}()
+
+	// imports carry whole export files, leave room for the other fields
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(ctx.Cfg.ImportMaxBytes + 1024*1024))
 	pb.RegisterChatServiceServer(server, &handler.ChatHandler{
-		Service: ctx.Ctl.ChatHandler.Service,
+		Service: services,
 		Logger:  ctx.Log,
 	})
 
+	go func() {
+		<-appContext.Done()
+		log.Println("Shutdown Server ...")
+		server.GracefulStop()
+	}()
+
 	log.Printf("chat-service started %s:%s", ctx.Cfg.GrpcIp, ctx.Cfg.GrpcPort)
 	if err = server.Serve(listener); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
+
+	// let a running sweep or dispatch finish its batch
+	jobs.Wait()
+	log.Println("Server exiting")
 }
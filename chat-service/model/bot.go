@@ -0,0 +1,73 @@
+package model
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Sender types, telling people apart from bots and the service itself
+const (
+	SenderTypeUser   = "user"
+	SenderTypeBot    = "bot"
+	SenderTypeSystem = "system"
+	SenderTypeHook   = "hook"
+	SenderTypeImport = "import"
+)
+
+// Bot posts into rooms in reply to the slash commands it registered. Only the
+// hash of its token is stored.
+type Bot struct {
+	gorm.Model
+	Name       string       `json:"name" gorm:"not null;uniqueIndex"`
+	TokenHash  string       `json:"-" gorm:"not null;uniqueIndex"`
+	OwnerEmail string       `json:"owner_email" gorm:"not null"`
+	Commands   []BotCommand `json:"commands" gorm:"foreignKey:BotID"`
+}
+
+// BotSender is the sender of the messages a bot posts
+func (b *Bot) BotSender() string {
+	return BotSender(b.Name)
+}
+
+func BotSender(name string) string {
+	return "bot:" + name
+}
+
+type BotCommand struct {
+	gorm.Model
+	BotID       uint   `json:"bot_id" gorm:"not null;index"`
+	Command     string `json:"command" gorm:"not null;uniqueIndex"` // without the slash
+	Description string `json:"description"`
+}
+
+// BotInstall lets a bot answer its commands in one room, a room admin installs it
+type BotInstall struct {
+	gorm.Model
+	RoomID      uint   `json:"room_id" gorm:"not null;uniqueIndex:idx_bot_install"`
+	BotID       uint   `json:"bot_id" gorm:"not null;uniqueIndex:idx_bot_install;index"`
+	InstalledBy string `json:"installed_by" gorm:"not null"`
+}
+
+// BotInvocation is a command sent to a bot, which it may answer in the room
+// until ExpiresAt
+type BotInvocation struct {
+	gorm.Model
+	BotID     uint      `json:"bot_id" gorm:"not null;index"`
+	RoomID    uint      `json:"room_id" gorm:"not null"`
+	UserEmail string    `json:"user_email" gorm:"not null"`
+	Command   string    `json:"command" gorm:"not null"`
+	Args      string    `json:"args"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CommandReply is shown to the user who ran a command only
+type CommandReply struct {
+	Command string `json:"command"`
+	Text    string `json:"text"`
+}
+
+// BotChannel is the redis channel the invocations of a bot go through
+func BotChannel(botID uint) string {
+	return fmt.Sprintf("bot:%d", botID)
+}
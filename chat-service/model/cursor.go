@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MessageCursor is a position in the history of a room. Messages are ordered by
+// creation time, and by ID when two were created at the same time.
+type MessageCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// CursorOf returns the position of a message
+func CursorOf(m Message) *MessageCursor {
+	return &MessageCursor{CreatedAt: m.CreatedAt, ID: m.ID}
+}
+
+// Encode turns the cursor into an opaque token for clients
+func (c *MessageCursor) Encode() string {
+	if c == nil {
+		return ""
+	}
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeMessageCursor parses a token made by Encode, an empty token is no cursor
+func DecodeMessageCursor(token string) (*MessageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var nanos int64
+	var id uint
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &id); err != nil || id == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &MessageCursor{CreatedAt: time.Unix(0, nanos), ID: id}, nil
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMessageCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor MessageCursor
+	}{
+		{name: "nanosecond precision", cursor: MessageCursor{CreatedAt: time.Date(2024, 5, 1, 10, 30, 0, 123456789, time.UTC), ID: 42}},
+		{name: "large id", cursor: MessageCursor{CreatedAt: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), ID: 1<<32 + 7}},
+		{name: "before the epoch", cursor: MessageCursor{CreatedAt: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), ID: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeMessageCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodeMessageCursor() error = %v", err)
+			}
+			if !got.CreatedAt.Equal(tt.cursor.CreatedAt) || got.ID != tt.cursor.ID {
+				t.Errorf("DecodeMessageCursor() = %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeMessageCursor(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *MessageCursor
+		wantErr bool
+	}{
+		{name: "empty token", token: ""},
+		{name: "valid", token: encode("1700000000000000000:9"), want: &MessageCursor{CreatedAt: time.Unix(0, 1700000000000000000), ID: 9}},
+		{name: "not base64", token: "!!!", wantErr: true},
+		{name: "missing id", token: encode("1700000000000000000"), wantErr: true},
+		{name: "zero id", token: encode("1700000000000000000:0"), wantErr: true},
+		{name: "not numbers", token: encode("yesterday:first"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeMessageCursor(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Fatalf("DecodeMessageCursor() error = %v, want ErrInvalidCursor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeMessageCursor() error = %v", err)
+			}
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("DecodeMessageCursor() = %+v, want nil", *got)
+				}
+				return
+			}
+			if got == nil || !got.CreatedAt.Equal(tt.want.CreatedAt) || got.ID != tt.want.ID {
+				t.Errorf("DecodeMessageCursor() = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeNilCursor(t *testing.T) {
+	var cursor *MessageCursor
+	if got := cursor.Encode(); got != "" {
+		t.Errorf("Encode() = %q, want empty", got)
+	}
+}
@@ -8,4 +8,6 @@ type Pagination struct {
 	RoomId     int       `json:"room_id"`
 	RoomName   string    `json:"room_name"`
 	Messages   []Message `json:"data"`
+	NextCursor string    `json:"next_cursor,omitempty"` // Older messages
+	PrevCursor string    `json:"prev_cursor,omitempty"` // Newer messages
 }
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Transcript formats of a room export
+const (
+	ExportFormatJSONL = "jsonl"
+	ExportFormatHTML  = "html"
+	ExportFormatText  = "text"
+)
+
+// ExportRoomHeader is the first line of a JSON Lines export
+type ExportRoomHeader struct {
+	Type         string    `json:"type"` // always "room"
+	RoomId       uint      `json:"roomId"`
+	Name         string    `json:"name"`
+	Participants []string  `json:"participants"`
+	ExportedAt   time.Time `json:"exportedAt"`
+}
+
+// ExportMessage is one message of a room export. Messages deleted for everyone
+// are kept as tombstones without their content.
+type ExportMessage struct {
+	Type           string          `json:"type"` // always "message"
+	Id             uint            `json:"id"`
+	Sender         string          `json:"sender"`
+	SenderType     string          `json:"senderType"`
+	MessageType    string          `json:"messageType"`
+	Content        string          `json:"content,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	ReplyTo        *uint           `json:"replyTo,omitempty"`
+	ForwardedFrom  *uint           `json:"forwardedFrom,omitempty"`
+	AttachmentUrls []string        `json:"attachmentUrls,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	EditedAt       *time.Time      `json:"editedAt,omitempty"`
+	Deleted        bool            `json:"deleted,omitempty"`
+}
+
+func ToExportMessage(m Message) ExportMessage {
+	export := ExportMessage{
+		Type:          "message",
+		Id:            m.ID,
+		Sender:        m.SenderEmail,
+		SenderType:    m.SenderType,
+		MessageType:   m.Type,
+		ReplyTo:       m.ReplyTo,
+		ForwardedFrom: m.ForwardedFrom,
+		CreatedAt:     m.CreatedAt,
+		EditedAt:      m.EditedAt,
+		Deleted:       m.DeletedAt.Valid,
+	}
+	if export.Deleted {
+		return export
+	}
+
+	export.Content = m.Content
+	if m.Payload != nil {
+		export.Payload = json.RawMessage(*m.Payload)
+	}
+	if m.AttachmentURL != nil && *m.AttachmentURL != "" {
+		export.AttachmentUrls = append(export.AttachmentUrls, *m.AttachmentURL)
+	}
+	if payload, err := m.DecodePayload(); err == nil {
+		if file, ok := payload.(*FilePayload); ok && file.URL != "" {
+			export.AttachmentUrls = append(export.AttachmentUrls, file.URL)
+		}
+	}
+	return export
+}
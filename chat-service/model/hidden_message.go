@@ -0,0 +1,11 @@
+package model
+
+import "gorm.io/gorm"
+
+// HiddenMessage marks a message deleted only for one participant
+type HiddenMessage struct {
+	gorm.Model
+	MessageID uint    `json:"message_id" gorm:"not null;uniqueIndex:idx_hidden_message_user"`
+	UserEmail string  `json:"user_email" gorm:"not null;uniqueIndex:idx_hidden_message_user"`
+	Message   Message `gorm:"foreignKey:MessageID"` // Relasi ke Message
+}
@@ -0,0 +1,60 @@
+package model
+
+import "gorm.io/gorm"
+
+// Messengers a chat can be imported from
+const (
+	ImportSourceWhatsApp = "whatsapp"
+	ImportSourceTelegram = "telegram"
+)
+
+// Import statuses
+const (
+	ImportStatusPending   = "pending" // Started, or failed and waiting to be resumed
+	ImportStatusCompleted = "completed"
+)
+
+// ChatImport tracks the import of one export file into a room. Sending the same
+// file again resumes an import that did not complete and returns one that did.
+type ChatImport struct {
+	gorm.Model
+	UserEmail string `json:"user_email" gorm:"not null;uniqueIndex:idx_chat_import_file"`
+	Checksum  string `json:"checksum" gorm:"not null;uniqueIndex:idx_chat_import_file"` // sha256 of the file
+	Source    string `json:"source" gorm:"not null"`
+	Status    string `json:"status" gorm:"not null;default:pending"`
+	RoomID    *uint  `json:"room_id"` // Set once completed
+	Imported  int    `json:"imported"`
+	Skipped   int    `json:"skipped"`
+	Failed    int    `json:"failed"` // Lines that could not be parsed
+	LastError string `json:"last_error"`
+}
+
+// ImportLineError is a line of an export that was left out
+type ImportLineError struct {
+	Line   int
+	Reason string
+}
+
+// ImportResult is the outcome of an import. Errors and unmapped senders are
+// only known when the file was parsed, not when a completed import is returned.
+type ImportResult struct {
+	Import          *ChatImport
+	Errors          []ImportLineError
+	UnmappedSenders []string // Display names no email was given for
+	Invites         []ImportInvite
+	AlreadyImported bool
+}
+
+// ImportInvite is the single use invite of a user mapped to a sender, who only
+// joins the imported room by accepting it
+type ImportInvite struct {
+	Email string
+	Token string
+}
+
+// ImportSender is the sender of imported messages, the display name of their
+// author in the export. Mapped emails are not used so an import cannot post as
+// another user.
+func ImportSender(name string) string {
+	return "import:" + name
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RoomInvite lets anyone holding its link join a room with Role. Only the hash
+// of its token is stored.
+type RoomInvite struct {
+	gorm.Model
+	RoomID    uint       `json:"room_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	Role      string     `json:"role" gorm:"not null;default:member"`
+	MaxUses   int        `json:"max_uses" gorm:"not null;default:0"` // 0 allows any number of uses
+	Uses      int        `json:"uses" gorm:"not null;default:0"`
+	ExpiresAt *time.Time `json:"expires_at"` // Never expires when nil
+	CreatedBy string     `json:"created_by" gorm:"not null"`
+	Email     string     `json:"email"` // Only this user may accept it when set
+}
+
+// Expired reports whether the invite can no longer be used at now
+func (i *RoomInvite) Expired(now time.Time) bool {
+	return i.ExpiresAt != nil && !now.Before(*i.ExpiresAt)
+}
+
+// UsedUp reports whether every use of the invite has been taken
+func (i *RoomInvite) UsedUp() bool {
+	return i.MaxUses > 0 && i.Uses >= i.MaxUses
+}
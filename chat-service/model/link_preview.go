@@ -0,0 +1,43 @@
+package model
+
+import "gorm.io/gorm"
+
+// LinkPreview is the metadata of a URL found in a message content
+type LinkPreview struct {
+	gorm.Model
+	MessageID   uint   `json:"message_id" gorm:"not null;index"`
+	URL         string `json:"url" gorm:"not null"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+	SiteName    string `json:"site_name"`
+}
+
+// SocketLinkPreview is a preview as published to the room
+type SocketLinkPreview struct {
+	Url         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageUrl    string `json:"imageUrl,omitempty"`
+	SiteName    string `json:"siteName,omitempty"`
+}
+
+// LinkPreviewEvent tells the room the previews of a message are ready
+type LinkPreviewEvent struct {
+	MessageId uint                `json:"messageId"`
+	Previews  []SocketLinkPreview `json:"previews"`
+}
+
+func ToSocketLinkPreviews(previews []LinkPreview) []SocketLinkPreview {
+	socketPreviews := make([]SocketLinkPreview, len(previews))
+	for i, p := range previews {
+		socketPreviews[i] = SocketLinkPreview{
+			Url:         p.URL,
+			Title:       p.Title,
+			Description: p.Description,
+			ImageUrl:    p.ImageURL,
+			SiteName:    p.SiteName,
+		}
+	}
+	return socketPreviews
+}
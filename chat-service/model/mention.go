@@ -0,0 +1,15 @@
+package model
+
+import "gorm.io/gorm"
+
+const EntityTypeMention = "mention"
+
+// Mention is a participant referenced in a message with @email or @handle
+type Mention struct {
+	gorm.Model
+	MessageID uint   `json:"message_id" gorm:"not null;index"`
+	RoomID    uint   `json:"room_id" gorm:"not null"`
+	UserEmail string `json:"user_email" gorm:"not null;index"`
+	Offset    int    `json:"offset"` // Position of the @ in the content, in characters
+	Length    int    `json:"length"` // Length of the token including the @, in characters
+}
@@ -5,13 +5,37 @@ import (
 	"time"
 )
 
+// SystemSender is the sender of the messages the service posts itself
+const SystemSender = "system"
+
 type Message struct {
 	gorm.Model
-	RoomID        uint       `json:"room_id"`
-	SenderEmail   string     `json:"sender_email"`
-	Content       string     `json:"content"`
-	AttachmentURL *string    `json:"attachment_url"`
-	ReplyTo       *uint      `json:"reply_to"`
-	ReadAt        *time.Time `json:"read_at"`
-	Room          Room       `gorm:"foreignKey:RoomID"` // Relasi ke Room
+	RoomID          uint             `json:"room_id"`
+	SenderEmail     string           `json:"sender_email"`
+	SenderType      string           `json:"sender_type" gorm:"not null;default:user"`
+	Type            string           `json:"type" gorm:"not null;default:text"`
+	Content         string           `json:"content"`
+	Payload         *string          `json:"payload" gorm:"type:jsonb"` // JSON matching Type, see payload.go
+	AttachmentURL   *string          `json:"attachment_url"`
+	ReplyTo         *uint            `json:"reply_to"`
+	ForwardedFrom   *uint            `json:"forwarded_from"`
+	ForwardedSender *string          `json:"forwarded_sender"` // Sender of the original message
+	EditedAt        *time.Time       `json:"edited_at"`
+	ExpiresAt       *time.Time       `json:"expires_at" gorm:"index"` // Set when the room has a retention period
+	Room            Room             `gorm:"foreignKey:RoomID"`       // Relasi ke Room
+	Receipts        []MessageReceipt `json:"receipts" gorm:"foreignKey:MessageID"`
+	Reactions       []Reaction       `json:"reactions" gorm:"foreignKey:MessageID"`
+	Mentions        []Mention        `json:"mentions" gorm:"foreignKey:MessageID"`
+	Poll            *Poll            `json:"poll" gorm:"foreignKey:MessageID"`
+	Previews        []LinkPreview    `json:"previews" gorm:"foreignKey:MessageID"`
+	ReplyCount      int              `json:"reply_count" gorm:"-"`
+	LastReplyAt     *time.Time       `json:"last_reply_at" gorm:"-"`
+	Highlight       string           `json:"highlight,omitempty" gorm:"->;-:migration"` // Filled by searches only
+}
+
+// ThreadSummary aggregates the direct replies of a message
+type ThreadSummary struct {
+	MessageID   uint
+	ReplyCount  int
+	LastReplyAt *time.Time
 }
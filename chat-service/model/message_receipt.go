@@ -0,0 +1,21 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusRead      = "read"
+)
+
+// MessageReceipt tracks when a message reached and was read by one participant
+type MessageReceipt struct {
+	gorm.Model
+	MessageID   uint       `json:"message_id" gorm:"not null;uniqueIndex:idx_message_receipt_user"`
+	UserEmail   string     `json:"user_email" gorm:"not null;uniqueIndex:idx_message_receipt_user"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+	ReadAt      *time.Time `json:"read_at"`
+}
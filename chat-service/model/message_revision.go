@@ -0,0 +1,11 @@
+package model
+
+import "gorm.io/gorm"
+
+// MessageRevision keeps the content a message had before it was edited
+type MessageRevision struct {
+	gorm.Model
+	MessageID uint    `json:"message_id" gorm:"not null;index"`
+	Content   string  `json:"content"`
+	Message   Message `gorm:"foreignKey:MessageID"` // Relasi ke Message
+}
@@ -0,0 +1,198 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"strings"
+)
+
+// Message types, the payload of a message is decoded according to its type
+const (
+	MessageTypeText     = "text"
+	MessageTypePoll     = "poll"
+	MessageTypeLocation = "location"
+	MessageTypeContact  = "contact"
+	MessageTypeFile     = "file"
+	MessageTypeSystem   = "system"
+)
+
+// System events
+const (
+	SystemEventMemberJoined     = "member_joined"
+	SystemEventRetentionChanged = "retention_changed"
+	SystemEventRoomRenamed      = "room_renamed"
+	SystemEventRoleChanged      = "role_changed"
+	SystemEventMemberRemoved    = "member_removed"
+	SystemEventMemberLeft       = "member_left"
+	SystemEventOwnerChanged     = "owner_changed"
+)
+
+var ErrInvalidPayload = errors.New("message payload does not match its type")
+
+type LocationPayload struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Name      string  `json:"name,omitempty"`
+	Address   string  `json:"address,omitempty"`
+}
+
+type ContactPayload struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+type FilePayload struct {
+	URL      string `json:"url"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType,omitempty"`
+	Size     int64  `json:"size,omitempty"` // bytes
+}
+
+type SystemPayload struct {
+	Event  string `json:"event"`
+	Actor  string `json:"actor,omitempty"`  // User who caused the event
+	Target string `json:"target,omitempty"` // User the event is about
+	Value  string `json:"value,omitempty"`  // New setting, such as the retention
+}
+
+type payload interface {
+	validate() error
+}
+
+func (p *LocationPayload) validate() error {
+	if math.IsNaN(p.Latitude) || math.Abs(p.Latitude) > 90 {
+		return fmt.Errorf("%w: latitude must be between -90 and 90", ErrInvalidPayload)
+	}
+	if math.IsNaN(p.Longitude) || math.Abs(p.Longitude) > 180 {
+		return fmt.Errorf("%w: longitude must be between -180 and 180", ErrInvalidPayload)
+	}
+	return nil
+}
+
+func (p *ContactPayload) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: contact name is required", ErrInvalidPayload)
+	}
+	if p.Email == "" && p.Phone == "" {
+		return fmt.Errorf("%w: contact needs an email or a phone", ErrInvalidPayload)
+	}
+	if p.Email != "" {
+		if _, err := mail.ParseAddress(p.Email); err != nil {
+			return fmt.Errorf("%w: invalid contact email", ErrInvalidPayload)
+		}
+	}
+	return nil
+}
+
+func (p *FilePayload) validate() error {
+	if strings.TrimSpace(p.URL) == "" || strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: file url and name are required", ErrInvalidPayload)
+	}
+	if p.Size < 0 {
+		return fmt.Errorf("%w: file size must not be negative", ErrInvalidPayload)
+	}
+	return nil
+}
+
+func (p *SystemPayload) validate() error {
+	if p.Event == "" {
+		return fmt.Errorf("%w: system event is required", ErrInvalidPayload)
+	}
+	return nil
+}
+
+// Text describes the event for clients that do not render system payloads
+func (p *SystemPayload) Text() string {
+	switch p.Event {
+	case SystemEventMemberJoined:
+		if p.Actor != "" && p.Actor != p.Target {
+			return fmt.Sprintf("%s added %s", p.Actor, p.Target)
+		}
+		return fmt.Sprintf("%s joined", p.Target)
+	case SystemEventRetentionChanged:
+		if p.Value == RetentionOff {
+			return fmt.Sprintf("%s turned off disappearing messages", p.Actor)
+		}
+		return fmt.Sprintf("%s set messages to disappear after %s", p.Actor, p.Value)
+	case SystemEventRoomRenamed:
+		return fmt.Sprintf("%s renamed the room to %s", p.Actor, p.Value)
+	case SystemEventRoleChanged:
+		return fmt.Sprintf("%s made %s %s", p.Actor, p.Target, p.Value)
+	case SystemEventMemberRemoved:
+		return fmt.Sprintf("%s removed %s", p.Actor, p.Target)
+	case SystemEventMemberLeft:
+		return fmt.Sprintf("%s left", p.Target)
+	case SystemEventOwnerChanged:
+		return fmt.Sprintf("%s made %s the owner", p.Actor, p.Target)
+	}
+	return p.Event
+}
+
+// newPayload returns the payload type of a message type, nil when the type has
+// no payload
+func newPayload(messageType string) (payload, error) {
+	switch messageType {
+	case "", MessageTypeText, MessageTypePoll:
+		return nil, nil
+	case MessageTypeLocation:
+		return &LocationPayload{}, nil
+	case MessageTypeContact:
+		return &ContactPayload{}, nil
+	case MessageTypeFile:
+		return &FilePayload{}, nil
+	case MessageTypeSystem:
+		return &SystemPayload{}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown message type %q", ErrInvalidPayload, messageType)
+}
+
+// SetPayload stores v as the payload of the message
+func (m *Message) SetPayload(messageType string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	encoded := string(data)
+	m.Type = messageType
+	m.Payload = &encoded
+	return nil
+}
+
+// DecodePayload returns the payload of the message, a *LocationPayload,
+// *ContactPayload, *FilePayload or *SystemPayload, or nil when it has none
+func (m *Message) DecodePayload() (any, error) {
+	p, err := newPayload(m.Type)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	if m.Payload == nil {
+		return nil, fmt.Errorf("%w: %s message without payload", ErrInvalidPayload, m.Type)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(*m.Payload)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return p, nil
+}
+
+// ValidatePayload checks the payload is the one the message type expects
+func (m *Message) ValidatePayload() error {
+	p, err := m.DecodePayload()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		if m.Payload != nil {
+			return fmt.Errorf("%w: %s message cannot have a payload", ErrInvalidPayload, m.Type)
+		}
+		return nil
+	}
+	return p.(payload).validate()
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidatePayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		messageType string
+		payload     *string
+		wantErr     bool
+	}{
+		{name: "text without payload", messageType: MessageTypeText},
+		{name: "untyped without payload", messageType: ""},
+		{name: "poll without payload", messageType: MessageTypePoll},
+		{name: "text with payload", messageType: MessageTypeText, payload: ptr(`{}`), wantErr: true},
+		{name: "unknown type", messageType: "sticker", wantErr: true},
+		{name: "location", messageType: MessageTypeLocation, payload: ptr(`{"latitude":-6.2,"longitude":106.8,"name":"Jakarta"}`)},
+		{name: "location latitude out of range", messageType: MessageTypeLocation, payload: ptr(`{"latitude":91,"longitude":0}`), wantErr: true},
+		{name: "location longitude out of range", messageType: MessageTypeLocation, payload: ptr(`{"latitude":0,"longitude":-180.5}`), wantErr: true},
+		{name: "location without payload", messageType: MessageTypeLocation, wantErr: true},
+		{name: "contact with email", messageType: MessageTypeContact, payload: ptr(`{"name":"Ana","email":"ana@example.com"}`)},
+		{name: "contact with phone", messageType: MessageTypeContact, payload: ptr(`{"name":"Ana","phone":"+62 812"}`)},
+		{name: "contact without name", messageType: MessageTypeContact, payload: ptr(`{"name":"  ","phone":"+62 812"}`), wantErr: true},
+		{name: "contact without email or phone", messageType: MessageTypeContact, payload: ptr(`{"name":"Ana"}`), wantErr: true},
+		{name: "contact with invalid email", messageType: MessageTypeContact, payload: ptr(`{"name":"Ana","email":"ana"}`), wantErr: true},
+		{name: "file", messageType: MessageTypeFile, payload: ptr(`{"url":"https://cdn.example.com/a.pdf","name":"a.pdf","size":120}`)},
+		{name: "file without url", messageType: MessageTypeFile, payload: ptr(`{"name":"a.pdf"}`), wantErr: true},
+		{name: "file with negative size", messageType: MessageTypeFile, payload: ptr(`{"url":"https://cdn.example.com/a.pdf","name":"a.pdf","size":-1}`), wantErr: true},
+		{name: "system", messageType: MessageTypeSystem, payload: ptr(`{"event":"member_joined","target":"ana@example.com"}`)},
+		{name: "system without event", messageType: MessageTypeSystem, payload: ptr(`{"actor":"ana@example.com"}`), wantErr: true},
+		{name: "unknown field", messageType: MessageTypeLocation, payload: ptr(`{"latitude":0,"longitude":0,"altitude":10}`), wantErr: true},
+		{name: "malformed json", messageType: MessageTypeFile, payload: ptr(`{"url":`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := Message{Type: tt.messageType, Payload: tt.payload}
+			err := message.ValidatePayload()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPayload) {
+					t.Fatalf("ValidatePayload() = %v, want ErrInvalidPayload", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidatePayload() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestSystemPayloadText(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload SystemPayload
+		want    string
+	}{
+		{
+			name:    "joined by invite",
+			payload: SystemPayload{Event: SystemEventMemberJoined, Actor: "ana@example.com", Target: "ana@example.com"},
+			want:    "ana@example.com joined",
+		},
+		{
+			name:    "added by admin",
+			payload: SystemPayload{Event: SystemEventMemberJoined, Actor: "bob@example.com", Target: "ana@example.com"},
+			want:    "bob@example.com added ana@example.com",
+		},
+		{
+			name:    "retention off",
+			payload: SystemPayload{Event: SystemEventRetentionChanged, Actor: "bob@example.com", Value: RetentionOff},
+			want:    "bob@example.com turned off disappearing messages",
+		},
+		{
+			name:    "unknown event",
+			payload: SystemPayload{Event: "room_archived"},
+			want:    "room_archived",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.Text(); got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// PinnedMessage records who pinned a message of a room and when
+type PinnedMessage struct {
+	gorm.Model
+	RoomID    uint      `json:"room_id" gorm:"not null;index"`
+	MessageID uint      `json:"message_id" gorm:"not null;uniqueIndex"`
+	PinnedBy  string    `json:"pinned_by" gorm:"not null"`
+	PinnedAt  time.Time `json:"pinned_at" gorm:"not null"`
+	Message   Message   `gorm:"foreignKey:MessageID"` // Relasi ke Message
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Poll is attached to the message that shows it in the room
+type Poll struct {
+	gorm.Model
+	MessageID      uint         `json:"message_id" gorm:"not null;uniqueIndex"`
+	RoomID         uint         `json:"room_id" gorm:"not null;index"`
+	Question       string       `json:"question" gorm:"not null"`
+	MultipleChoice bool         `json:"multiple_choice"`
+	Anonymous      bool         `json:"anonymous"` // Voters are never returned
+	CreatedBy      string       `json:"created_by" gorm:"not null"`
+	ClosedAt       *time.Time   `json:"closed_at"`
+	Options        []PollOption `json:"options" gorm:"foreignKey:PollID"`
+	Votes          []PollVote   `json:"votes" gorm:"foreignKey:PollID"`
+}
+
+type PollOption struct {
+	gorm.Model
+	PollID   uint   `json:"poll_id" gorm:"not null;index"`
+	Text     string `json:"text" gorm:"not null"`
+	Position int    `json:"position"`
+}
+
+// PollVote is the choice of one room participant, a participant has at most one
+// vote per option
+type PollVote struct {
+	gorm.Model
+	PollID            uint            `json:"poll_id" gorm:"not null;index"`
+	OptionID          uint            `json:"option_id" gorm:"not null;uniqueIndex:idx_poll_vote_participant"`
+	RoomParticipantID uint            `json:"room_participant_id" gorm:"not null;uniqueIndex:idx_poll_vote_participant"`
+	UserEmail         string          `json:"user_email" gorm:"not null"`
+	RoomParticipant   RoomParticipant `gorm:"foreignKey:RoomParticipantID"`
+}
+
+// PollTally is the public state of a poll, as pushed to every participant
+type PollTally struct {
+	PollId         uint              `json:"pollId"`
+	MessageId      uint              `json:"messageId"`
+	Question       string            `json:"question"`
+	MultipleChoice bool              `json:"multipleChoice"`
+	Anonymous      bool              `json:"anonymous"`
+	CreatedBy      string            `json:"createdBy"`
+	ClosedAt       *time.Time        `json:"closedAt,omitempty"`
+	Options        []PollOptionTally `json:"options"`
+	TotalVoters    int               `json:"totalVoters"`
+}
+
+type PollOptionTally struct {
+	OptionId uint     `json:"optionId"`
+	Text     string   `json:"text"`
+	Votes    int      `json:"votes"`
+	Voters   []string `json:"voters,omitempty"` // Never set for anonymous polls
+}
+
+// Tally counts the votes of each option, Votes must be loaded
+func (p *Poll) Tally() PollTally {
+	voters := make(map[uint][]string, len(p.Options))
+	participants := make(map[uint]bool)
+	for _, v := range p.Votes {
+		voters[v.OptionID] = append(voters[v.OptionID], v.UserEmail)
+		participants[v.RoomParticipantID] = true
+	}
+
+	tally := PollTally{
+		PollId:         p.ID,
+		MessageId:      p.MessageID,
+		Question:       p.Question,
+		MultipleChoice: p.MultipleChoice,
+		Anonymous:      p.Anonymous,
+		CreatedBy:      p.CreatedBy,
+		ClosedAt:       p.ClosedAt,
+		Options:        make([]PollOptionTally, len(p.Options)),
+		TotalVoters:    len(participants),
+	}
+	for i, o := range p.Options {
+		tally.Options[i] = PollOptionTally{
+			OptionId: o.ID,
+			Text:     o.Text,
+			Votes:    len(voters[o.ID]),
+		}
+		if !p.Anonymous {
+			tally.Options[i].Voters = voters[o.ID]
+		}
+	}
+	return tally
+}
+
+// VotesOf returns the options userEmail voted for
+func (p *Poll) VotesOf(userEmail string) []uint {
+	var options []uint
+	for _, v := range p.Votes {
+		if v.UserEmail == userEmail {
+			options = append(options, v.OptionID)
+		}
+	}
+	return options
+}
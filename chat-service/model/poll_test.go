@@ -0,0 +1,92 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestPollTally(t *testing.T) {
+	options := []PollOption{
+		{Model: gorm.Model{ID: 1}, Text: "Tea", Position: 0},
+		{Model: gorm.Model{ID: 2}, Text: "Coffee", Position: 1},
+		{Model: gorm.Model{ID: 3}, Text: "Water", Position: 2},
+	}
+	vote := func(optionID, participantID uint, email string) PollVote {
+		return PollVote{OptionID: optionID, RoomParticipantID: participantID, UserEmail: email}
+	}
+
+	tests := []struct {
+		name       string
+		anonymous  bool
+		votes      []PollVote
+		wantVotes  []int
+		wantVoters [][]string
+		wantTotal  int
+	}{
+		{
+			name:       "no votes",
+			wantVotes:  []int{0, 0, 0},
+			wantVoters: [][]string{nil, nil, nil},
+		},
+		{
+			name:       "single choice",
+			votes:      []PollVote{vote(1, 10, "ana@example.com"), vote(2, 11, "bob@example.com"), vote(1, 12, "cid@example.com")},
+			wantVotes:  []int{2, 1, 0},
+			wantVoters: [][]string{{"ana@example.com", "cid@example.com"}, {"bob@example.com"}, nil},
+			wantTotal:  3,
+		},
+		{
+			name:       "multiple choice counts voters once",
+			votes:      []PollVote{vote(1, 10, "ana@example.com"), vote(2, 10, "ana@example.com"), vote(3, 11, "bob@example.com")},
+			wantVotes:  []int{1, 1, 1},
+			wantVoters: [][]string{{"ana@example.com"}, {"ana@example.com"}, {"bob@example.com"}},
+			wantTotal:  2,
+		},
+		{
+			name:       "anonymous hides voters",
+			anonymous:  true,
+			votes:      []PollVote{vote(2, 10, "ana@example.com"), vote(2, 11, "bob@example.com")},
+			wantVotes:  []int{0, 2, 0},
+			wantVoters: [][]string{nil, nil, nil},
+			wantTotal:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := Poll{
+				Model:     gorm.Model{ID: 5},
+				MessageID: 50,
+				Question:  "Drink?",
+				Anonymous: tt.anonymous,
+				CreatedBy: "ana@example.com",
+				Options:   options,
+				Votes:     tt.votes,
+			}
+			tally := poll.Tally()
+
+			if tally.PollId != 5 || tally.MessageId != 50 || tally.Question != "Drink?" || tally.Anonymous != tt.anonymous {
+				t.Errorf("Tally() header = %+v", tally)
+			}
+			if tally.TotalVoters != tt.wantTotal {
+				t.Errorf("TotalVoters = %d, want %d", tally.TotalVoters, tt.wantTotal)
+			}
+			if len(tally.Options) != len(options) {
+				t.Fatalf("len(Options) = %d, want %d", len(tally.Options), len(options))
+			}
+			for i, o := range tally.Options {
+				if o.OptionId != options[i].ID || o.Text != options[i].Text {
+					t.Errorf("Options[%d] = %+v, want option %d %q", i, o, options[i].ID, options[i].Text)
+				}
+				if o.Votes != tt.wantVotes[i] {
+					t.Errorf("Options[%d].Votes = %d, want %d", i, o.Votes, tt.wantVotes[i])
+				}
+				if !reflect.DeepEqual(o.Voters, tt.wantVoters[i]) {
+					t.Errorf("Options[%d].Voters = %v, want %v", i, o.Voters, tt.wantVoters[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package model
+
+import "gorm.io/gorm"
+
+// Reaction is one emoji a participant put on a message
+type Reaction struct {
+	gorm.Model
+	MessageID uint   `json:"message_id" gorm:"not null;uniqueIndex:idx_reaction_message_user_emoji"`
+	UserEmail string `json:"user_email" gorm:"not null;uniqueIndex:idx_reaction_message_user_emoji"`
+	Emoji     string `json:"emoji" gorm:"not null;uniqueIndex:idx_reaction_message_user_emoji"`
+}
+
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// CountReactions groups reactions per emoji, in the order each emoji was first used
+func CountReactions(reactions []Reaction) []ReactionCount {
+	var counts []ReactionCount
+	index := make(map[string]int)
+	for _, r := range reactions {
+		i, ok := index[r.Emoji]
+		if !ok {
+			i = len(counts)
+			index[r.Emoji] = i
+			counts = append(counts, ReactionCount{Emoji: r.Emoji})
+		}
+		counts[i].Count++
+	}
+	return counts
+}
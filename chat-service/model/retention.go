@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Retention settings of a room, messages disappear this long after being sent
+const (
+	RetentionOff = "off"
+	Retention24h = "24h"
+	Retention7d  = "7d"
+	Retention90d = "90d"
+)
+
+var retentionPeriods = map[string]time.Duration{
+	Retention24h: 24 * time.Hour,
+	Retention7d:  7 * 24 * time.Hour,
+	Retention90d: 90 * 24 * time.Hour,
+}
+
+// ValidRetention reports whether setting is one of the retention settings
+func ValidRetention(setting string) bool {
+	_, ok := retentionPeriods[setting]
+	return ok || setting == RetentionOff
+}
+
+// MessageExpiry returns when a message sent at sentAt to the room expires, nil
+// when messages of the room are kept
+func (r *Room) MessageExpiry(sentAt time.Time) *time.Time {
+	period, ok := retentionPeriods[r.Retention]
+	if !ok {
+		return nil
+	}
+	expiresAt := sentAt.Add(period)
+	return &expiresAt
+}
@@ -5,6 +5,8 @@ import "gorm.io/gorm"
 type Room struct {
 	gorm.Model
 	Name         string            `json:"name" gorm:"default:pv"`
+	PinLimit     int               `json:"pin_limit"` // 0 falls back to the service default
+	Retention    string            `json:"retention" gorm:"not null;default:off"`
 	Participants []RoomParticipant `json:"participants" gorm:"foreignKey:RoomID"`
 	Messages     []Message         `json:"messages" gorm:"foreignKey:RoomID"`
 }
@@ -2,11 +2,24 @@ package model
 
 import "gorm.io/gorm"
 
+// Participant roles. The owner created the room, admins manage it with them.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
 type RoomParticipant struct {
 	gorm.Model
 	RoomID    uint   `gorm:"not null"`
 	UserID    uint   `gorm:"not null"`
 	UserEmail string `gorm:"not null"`
+	Role      string `gorm:"not null;default:member"`
 	Room      Room   `gorm:"foreignKey:RoomID"` // Relasi ke Room
 	// User   User `gorm:"foreignKey:UserID"` // Relasi ke User
 }
+
+// IsAdmin reports whether the participant manages the room, owners included
+func (p *RoomParticipant) IsAdmin() bool {
+	return p.Role == RoleOwner || p.Role == RoleAdmin
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// RoomSummary is one entry of a user's room list
+type RoomSummary struct {
+	RoomID            uint
+	RoomName          string
+	LastMessageID     *uint
+	LastMessageSender *string
+	LastMessageText   *string
+	LastMessageAt     *time.Time
+	LastActivityAt    time.Time // Last message time, or room creation time when empty
+	UnreadCount       int
+}
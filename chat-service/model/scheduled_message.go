@@ -0,0 +1,29 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	ScheduledStatusPending  = "pending"
+	ScheduledStatusSending  = "sending"
+	ScheduledStatusSent     = "sent"
+	ScheduledStatusCanceled = "canceled"
+	ScheduledStatusFailed   = "failed"
+)
+
+// ScheduledMessage is a message composed now and sent to its room at SendAt
+type ScheduledMessage struct {
+	gorm.Model
+	RoomID        uint       `json:"room_id" gorm:"not null;index"`
+	SenderEmail   string     `json:"sender_email" gorm:"not null"`
+	Content       string     `json:"content"`
+	AttachmentURL *string    `json:"attachment_url"`
+	ReplyTo       *uint      `json:"reply_to"`
+	SendAt        time.Time  `json:"send_at" gorm:"not null;index:idx_scheduled_due,priority:2"`
+	Status        string     `json:"status" gorm:"not null;default:pending;index:idx_scheduled_due,priority:1"`
+	ClaimedAt     *time.Time `json:"claimed_at"`
+	MessageID     *uint      `json:"message_id"` // The message it was sent as
+	Error         string     `json:"error"`
+}
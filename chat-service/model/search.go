@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// MessageSearch holds the filters of a full-text search over the rooms of UserEmail
+type MessageSearch struct {
+	UserEmail   string
+	Query       string
+	RoomID      uint
+	SenderEmail string
+	From        *time.Time
+	To          *time.Time
+}
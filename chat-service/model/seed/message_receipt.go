@@ -0,0 +1,17 @@
+package seed
+
+import (
+	"project/chat-service/helper"
+	"project/chat-service/model"
+)
+
+func MessageReceiptSeed() []model.MessageReceipt {
+	return []model.MessageReceipt{
+		{
+			MessageID:   2,
+			UserEmail:   "dua@mail.com",
+			DeliveredAt: helper.Ptr(helper.DateTime("2024-12-28 10:10:02")),
+			ReadAt:      helper.Ptr(helper.DateTime("2024-12-28 14:15:11")),
+		},
+	}
+}
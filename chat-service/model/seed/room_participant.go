@@ -4,10 +4,10 @@ import "project/chat-service/model"
 
 func RoomParticipantSeed() []model.RoomParticipant {
 	return []model.RoomParticipant{
-		{RoomID: 1, UserEmail: "satu@mail.com"},
-		{RoomID: 1, UserEmail: "dua@mail.com"},
-		{RoomID: 2, UserEmail: "satu@mail.com"},
-		{RoomID: 2, UserEmail: "tiga@mail.com"},
-		{RoomID: 2, UserEmail: "empat@mail.com"},
+		{RoomID: 1, UserEmail: "satu@mail.com", Role: model.RoleOwner},
+		{RoomID: 1, UserEmail: "dua@mail.com", Role: model.RoleMember},
+		{RoomID: 2, UserEmail: "satu@mail.com", Role: model.RoleOwner},
+		{RoomID: 2, UserEmail: "tiga@mail.com", Role: model.RoleAdmin},
+		{RoomID: 2, UserEmail: "empat@mail.com", Role: model.RoleMember},
 	}
 }
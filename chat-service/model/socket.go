@@ -0,0 +1,74 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SocketMessage is a chat message as published on its room channel, the
+// events around it are defined by the realtime package
+type SocketMessage struct {
+	Id            uint            `json:"id"`
+	RoomId        uint            `json:"roomId"`
+	Sender        string          `json:"sender,omitempty"`
+	SenderType    string          `json:"senderType,omitempty"`
+	Type          string          `json:"type,omitempty"`
+	Content       string          `json:"content,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	AttachmentUrl string          `json:"attachmentUrl,omitempty"`
+	ReplyTo       int             `json:"replyTo,omitempty"`
+	ForwardedFrom uint            `json:"forwardedFrom,omitempty"`
+	// ForwardedSender is the sender of the original message of a forwarded copy
+	ForwardedSender string         `json:"forwardedSender,omitempty"`
+	Entities        []SocketEntity `json:"entities,omitempty"`
+	Poll            *PollTally     `json:"poll,omitempty"`
+	CreatedAt       time.Time      `json:"created_at,omitempty"`
+}
+
+type SocketEntity struct {
+	Type      string `json:"type"`
+	Offset    uint32 `json:"offset"`
+	Length    uint32 `json:"length"`
+	UserEmail string `json:"userEmail,omitempty"`
+}
+
+// ToSocketMessage converts a stored message into its published form
+func ToSocketMessage(m *Message) SocketMessage {
+	socketMessage := SocketMessage{
+		Id:         m.ID,
+		RoomId:     m.RoomID,
+		Sender:     m.SenderEmail,
+		SenderType: m.SenderType,
+		Type:       m.Type,
+		Content:    m.Content,
+		CreatedAt:  m.CreatedAt,
+	}
+	if m.Payload != nil {
+		socketMessage.Payload = json.RawMessage(*m.Payload)
+	}
+	if m.AttachmentURL != nil {
+		socketMessage.AttachmentUrl = *m.AttachmentURL
+	}
+	if m.ReplyTo != nil {
+		socketMessage.ReplyTo = int(*m.ReplyTo)
+	}
+	if m.ForwardedFrom != nil {
+		socketMessage.ForwardedFrom = *m.ForwardedFrom
+	}
+	if m.ForwardedSender != nil {
+		socketMessage.ForwardedSender = *m.ForwardedSender
+	}
+	if m.Poll != nil {
+		tally := m.Poll.Tally()
+		socketMessage.Poll = &tally
+	}
+	for _, mention := range m.Mentions {
+		socketMessage.Entities = append(socketMessage.Entities, SocketEntity{
+			Type:      EntityTypeMention,
+			Offset:    uint32(mention.Offset),
+			Length:    uint32(mention.Length),
+			UserEmail: mention.UserEmail,
+		})
+	}
+	return socketMessage
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// Webhook events
+const (
+	WebhookEventMessageCreated = "message.created"
+	WebhookEventMessageEdited  = "message.edited"
+	WebhookEventMessageDeleted = "message.deleted"
+)
+
+// Delivery statuses
+const (
+	DeliveryStatusPending   = "pending" // Waiting for its first or next attempt
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed" // Gave up after the last attempt
+)
+
+// RoomWebhook receives the activity of a room. The secret signs every payload.
+type RoomWebhook struct {
+	gorm.Model
+	RoomID    uint   `json:"room_id" gorm:"not null;index"`
+	URL       string `json:"url" gorm:"not null"`
+	Secret    string `json:"-" gorm:"not null"`
+	CreatedBy string `json:"created_by" gorm:"not null"`
+}
+
+// WebhookDelivery is one event sent to a webhook, with the outcome of its
+// latest attempt
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookID      uint       `json:"webhook_id" gorm:"not null;index"`
+	Event          string     `json:"event" gorm:"not null"`
+	Payload        string     `json:"payload" gorm:"type:jsonb;not null"`
+	Status         string     `json:"status" gorm:"not null;default:pending"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+// WebhookPayload is the JSON body posted to webhooks
+type WebhookPayload struct {
+	Event      string        `json:"event"`
+	RoomId     uint          `json:"roomId"`
+	Message    SocketMessage `json:"message"`
+	OccurredAt time.Time     `json:"occurredAt"`
+}
+
+// DeliveryPage is one page of the delivery log of a webhook
+type DeliveryPage struct {
+	Deliveries []WebhookDelivery
+	Page       int
+	Limit      int
+	TotalItems int
+	TotalPages int
+}
+
+// IncomingWebhook lets an outside service post into a room with a secret URL.
+// Only the hash of its token is stored.
+type IncomingWebhook struct {
+	gorm.Model
+	RoomID    uint   `json:"room_id" gorm:"not null;index"`
+	Name      string `json:"name" gorm:"not null"`
+	TokenHash string `json:"-" gorm:"not null;uniqueIndex"`
+	CreatedBy string `json:"created_by" gorm:"not null"`
+}
+
+// HookSender is the sender of the messages posted through an incoming webhook
+func HookSender(name string) string {
+	return "hook:" + name
+}
+
+// HookMessage is a Slack compatible incoming webhook payload
+type HookMessage struct {
+	Text        string
+	Username    string // Shown instead of the webhook name
+	Attachments []HookAttachment
+}
+
+type HookAttachment struct {
+	Fallback  string
+	Color     string
+	Pretext   string
+	Title     string
+	TitleLink string
+	Text      string
+	ImageURL  string
+}
+
+// Content flattens the message and its attachments into plain text, using
+// the fallback of attachments that have nothing else to show
+func (m HookMessage) Content() string {
+	lines := make([]string, 0, 1+len(m.Attachments))
+	if text := strings.TrimSpace(m.Text); text != "" {
+		lines = append(lines, text)
+	}
+	for _, a := range m.Attachments {
+		before := len(lines)
+		title := strings.TrimSpace(a.Title)
+		if link := strings.TrimSpace(a.TitleLink); link != "" {
+			if title == "" {
+				title = link
+			} else {
+				title += " (" + link + ")"
+			}
+		}
+		for _, line := range []string{a.Pretext, title, a.Text, a.ImageURL} {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) == before {
+			if fallback := strings.TrimSpace(a.Fallback); fallback != "" {
+				lines = append(lines, fallback)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,58 @@
+package model
+
+import "testing"
+
+func TestHookMessageContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		message HookMessage
+		want    string
+	}{
+		{
+			name:    "text only",
+			message: HookMessage{Text: "  Build passed  "},
+			want:    "Build passed",
+		},
+		{
+			name:    "empty",
+			message: HookMessage{Text: " ", Attachments: []HookAttachment{{}}},
+			want:    "",
+		},
+		{
+			name: "attachment fields in order",
+			message: HookMessage{
+				Text: "Deploy",
+				Attachments: []HookAttachment{{
+					Fallback:  "unused",
+					Pretext:   "Production",
+					Title:     "Release 1.2",
+					TitleLink: "https://ci.example.com/1.2",
+					Text:      "All green",
+					ImageURL:  "https://ci.example.com/badge.png",
+				}},
+			},
+			want: "Deploy\nProduction\nRelease 1.2 (https://ci.example.com/1.2)\nAll green\nhttps://ci.example.com/badge.png",
+		},
+		{
+			name:    "title link without title",
+			message: HookMessage{Attachments: []HookAttachment{{TitleLink: "https://ci.example.com/1.2"}}},
+			want:    "https://ci.example.com/1.2",
+		},
+		{
+			name: "fallback when nothing else",
+			message: HookMessage{
+				Text:        "Alert",
+				Attachments: []HookAttachment{{Fallback: "CPU above 90%", Color: "danger"}, {Text: "Disk ok"}},
+			},
+			want: "Alert\nCPU above 90%\nDisk ok",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.message.Content(); got != tt.want {
+				t.Errorf("Content() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
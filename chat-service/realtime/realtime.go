@@ -0,0 +1,59 @@
+// Package realtime is the contract of the updates published on redis for the
+// websockets held by the api-gateway. Both the chat-service and the gateway
+// publish through it, so it must not depend on either of them.
+package realtime
+
+import "fmt"
+
+// Event types, a payload without one is a plain chat message
+const (
+	EventMessageEdited    = "message_edited"
+	EventMessageDeleted   = "message_deleted"
+	EventMessagesRead     = "messages_read"
+	EventMessageDelivered = "message_delivered"
+	EventReactionAdded    = "reaction_added"
+	EventReactionRemoved  = "reaction_removed"
+	EventMessagePinned    = "message_pinned"
+	EventMessageUnpinned  = "message_unpinned"
+	EventMention          = "mention"
+	EventPollUpdated      = "poll_updated"
+	EventCommandReply     = "command_reply"
+	EventLinkPreview      = "link_preview"
+	// EventParticipantRemoved closes the removed user's websockets on the room
+	EventParticipantRemoved = "participant_removed"
+)
+
+// Event is published to a room channel so open websockets can update live.
+// Events with a Recipient are only delivered to that user's connections.
+type Event struct {
+	Type      string `json:"type"`
+	RoomId    uint   `json:"roomId"`
+	Recipient string `json:"recipient,omitempty"`
+	Data      any    `json:"data"`
+}
+
+// RoomChannel is the redis channel of a room
+func RoomChannel(roomID uint) string {
+	return fmt.Sprintf("room:%d", roomID)
+}
+
+// UserChannel is the redis channel of events addressed to one user, whichever
+// room their websockets are open on
+func UserChannel(email string) string {
+	return "user:" + email
+}
+
+// MentionRecipients returns who gets a mention event for a message, each
+// mentioned user once and never the sender
+func MentionRecipients(sender string, mentioned []string) []string {
+	notified := map[string]bool{sender: true}
+	var recipients []string
+	for _, email := range mentioned {
+		if notified[email] {
+			continue
+		}
+		notified[email] = true
+		recipients = append(recipients, email)
+	}
+	return recipients
+}
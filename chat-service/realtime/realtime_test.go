@@ -0,0 +1,29 @@
+package realtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentionRecipients(t *testing.T) {
+	tests := []struct {
+		name      string
+		sender    string
+		mentioned []string
+		want      []string
+	}{
+		{name: "no mentions", sender: "ana@example.com", want: nil},
+		{name: "others in order", sender: "ana@example.com", mentioned: []string{"bob@example.com", "cid@example.com"}, want: []string{"bob@example.com", "cid@example.com"}},
+		{name: "skips the sender", sender: "ana@example.com", mentioned: []string{"ana@example.com", "bob@example.com"}, want: []string{"bob@example.com"}},
+		{name: "only the sender", sender: "ana@example.com", mentioned: []string{"ana@example.com"}, want: nil},
+		{name: "each user once", sender: "ana@example.com", mentioned: []string{"bob@example.com", "cid@example.com", "bob@example.com"}, want: []string{"bob@example.com", "cid@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MentionRecipients(tt.sender, tt.mentioned); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MentionRecipients(%q, %q) = %q, want %q", tt.sender, tt.mentioned, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"errors"
+	"project/chat-service/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrCommandTaken is returned when another bot already registered a command
+	ErrCommandTaken = errors.New("command is already registered by another bot")
+	ErrBotNameTaken = errors.New("bot name is already taken")
+)
+
+type BotRepository interface {
+	CreateBot(bot *model.Bot) error
+	GetBotByTokenHash(tokenHash string) (*model.Bot, error)
+	ReplaceBotCommands(botID uint, commands []model.BotCommand) error
+	GetBotByName(name string) (*model.Bot, error)
+	GetBotCommand(command string) (*model.BotCommand, *model.Bot, error)
+	GetBotCommands(roomID uint) ([]model.BotCommand, error)
+	InstallBot(install *model.BotInstall) error
+	UninstallBot(roomID, botID uint) (bool, error)
+	IsBotInstalled(roomID, botID uint) (bool, error)
+	CreateInvocation(invocation *model.BotInvocation) error
+	GetInvocation(id uint) (*model.BotInvocation, error)
+}
+
+type botRepository struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewBotRepository(db *gorm.DB, log *zap.Logger) BotRepository {
+	return &botRepository{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *botRepository) CreateBot(bot *model.Bot) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&model.Bot{}).Where("name = ?", bot.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrBotNameTaken
+		}
+		return tx.Create(bot).Error
+	})
+}
+
+func (r *botRepository) GetBotByTokenHash(tokenHash string) (*model.Bot, error) {
+	var bot model.Bot
+	if err := r.DB.Preload("Commands").Where("token_hash = ?", tokenHash).First(&bot).Error; err != nil {
+		return nil, err
+	}
+	return &bot, nil
+}
+
+// ReplaceBotCommands sets the commands of a bot to exactly commands
+func (r *botRepository) ReplaceBotCommands(botID uint, commands []model.BotCommand) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		names := make([]string, len(commands))
+		for i := range commands {
+			commands[i].BotID = botID
+			names[i] = commands[i].Command
+		}
+
+		var taken int64
+		if len(names) > 0 {
+			if err := tx.Model(&model.BotCommand{}).
+				Where("command IN ? AND bot_id <> ?", names, botID).
+				Count(&taken).Error; err != nil {
+				return err
+			}
+		}
+		if taken > 0 {
+			return ErrCommandTaken
+		}
+
+		if err := tx.Unscoped().Where("bot_id = ?", botID).Delete(&model.BotCommand{}).Error; err != nil {
+			return err
+		}
+		if len(commands) == 0 {
+			return nil
+		}
+		return tx.Create(&commands).Error
+	})
+}
+
+// GetBotCommand finds the bot answering a command
+func (r *botRepository) GetBotCommand(command string) (*model.BotCommand, *model.Bot, error) {
+	var botCommand model.BotCommand
+	if err := r.DB.Where("command = ?", command).First(&botCommand).Error; err != nil {
+		return nil, nil, err
+	}
+	var bot model.Bot
+	if err := r.DB.First(&bot, botCommand.BotID).Error; err != nil {
+		return nil, nil, err
+	}
+	return &botCommand, &bot, nil
+}
+
+func (r *botRepository) GetBotByName(name string) (*model.Bot, error) {
+	var bot model.Bot
+	if err := r.DB.Where("name = ?", name).First(&bot).Error; err != nil {
+		return nil, err
+	}
+	return &bot, nil
+}
+
+// GetBotCommands lists the commands of the bots installed in a room
+func (r *botRepository) GetBotCommands(roomID uint) ([]model.BotCommand, error) {
+	var commands []model.BotCommand
+	err := r.DB.
+		Joins("JOIN bot_installs ON bot_installs.bot_id = bot_commands.bot_id AND bot_installs.deleted_at IS NULL").
+		Where("bot_installs.room_id = ?", roomID).
+		Order("command").
+		Find(&commands).Error
+	if err != nil {
+		return nil, err
+	}
+	return commands, nil
+}
+
+// InstallBot adds a bot to a room, installing it again changes nothing
+func (r *botRepository) InstallBot(install *model.BotInstall) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(install).Error
+}
+
+// UninstallBot removes a bot from a room and reports whether it was installed
+func (r *botRepository) UninstallBot(roomID, botID uint) (bool, error) {
+	result := r.DB.Unscoped().
+		Where("room_id = ? AND bot_id = ?", roomID, botID).
+		Delete(&model.BotInstall{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *botRepository) IsBotInstalled(roomID, botID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&model.BotInstall{}).
+		Where("room_id = ? AND bot_id = ?", roomID, botID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *botRepository) CreateInvocation(invocation *model.BotInvocation) error {
+	return r.DB.Create(invocation).Error
+}
+
+func (r *botRepository) GetInvocation(id uint) (*model.BotInvocation, error) {
+	var invocation model.BotInvocation
+	if err := r.DB.First(&invocation, id).Error; err != nil {
+		return nil, err
+	}
+	return &invocation, nil
+}
@@ -1,24 +1,68 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
+	"html"
 	"project/chat-service/config"
 	"project/chat-service/database"
 	"project/chat-service/model"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrAlreadyParticipant is returned when a user joins a room twice
+var ErrAlreadyParticipant = errors.New("user is already a participant of this room")
+
 type ChatRepository interface {
 	GetUserDetails(userID uint) (*model.User, error)
 	CreateRoom(room *model.Room) error
 	CreateRoomParticipant(roomParticipant *model.RoomParticipant) error
 	SaveMessage(message *model.Message) error
 	GetRoomParticipants(roomID uint) ([]model.RoomParticipant, error)
-	GetRoomMessages(roomID uint, limit int, offset int) (*model.Pagination, error)
+	GetRoomMessages(roomID uint, userEmail string, limit int, offset int) (*model.Pagination, error)
+	GetRoomMessagesByCursor(roomID uint, userEmail string, limit int, cursor *model.MessageCursor, older bool) (*model.Pagination, error)
+	GetRoomMessagesAfter(roomID uint, cursor *model.MessageCursor, limit int) ([]model.Message, error)
 	GetRoomByID(roomID uint) (*model.Room, error)
+	GetMessageByID(messageID uint) (*model.Message, error)
+	EditMessage(message *model.Message, content string, mentions []model.Mention) error
+	DeleteMessage(message *model.Message) error
+	HideMessage(messageID uint, userEmail string) error
+	IsRoomParticipant(roomID uint, userEmail string) (bool, error)
+	GetRoomParticipant(roomID uint, userEmail string) (*model.RoomParticipant, error)
+	SetParticipantRole(participantID uint, role string) error
+	// DeleteRoomParticipant also deletes the participant's poll votes
+	DeleteRoomParticipant(participantID uint) error
+	// TransferOwnership makes newOwner the owner and the previous owner an admin
+	TransferOwnership(owner, newOwner *model.RoomParticipant) error
+	RenameRoom(roomID uint, name string) error
+	MarkRead(roomID uint, userEmail string, upToMessageID uint, readAt time.Time) (int64, error)
+	MarkDelivered(messageID uint, userEmail string, deliveredAt time.Time) (bool, error)
+	AddReaction(reaction *model.Reaction) error
+	RemoveReaction(messageID uint, userEmail, emoji string) error
+	GetReactions(messageID uint) ([]model.Reaction, error)
+	GetThreadReplies(roomID, rootID uint, userEmail string, limit int, offset int) (*model.Pagination, error)
+	GetMessageDetails(messageID uint, userEmail string) (*model.Message, error)
+	PinMessage(pin *model.PinnedMessage, limit int) error
+	UnpinMessage(roomID, messageID uint) (bool, error)
+	GetPinnedMessages(roomID uint) ([]model.PinnedMessage, error)
+	SaveMessages(messages []model.Message) error
+	SearchMessages(search model.MessageSearch, limit int, offset int) (*model.Pagination, error)
+	GetUserRooms(userEmail string) ([]model.RoomSummary, error)
+	GetUsersByUsernames(usernames []string) ([]model.User, error)
+	GetMentions(userEmail string, limit int, offset int) (*model.Pagination, error)
+	SetRoomRetention(roomID uint, retention string) error
+	DeleteExpiredMessages(now time.Time, limit int) ([]string, int, error)
+	SaveLinkPreviews(previews []model.LinkPreview) error
 }
 
+// ErrPinLimitReached is returned when a room already has as many pins as allowed
+var ErrPinLimitReached = errors.New("pinned message limit reached for this room")
+
 type chatRepository struct {
 	DB     *gorm.DB
 	Cacher database.Cacher
@@ -46,14 +90,28 @@ func (r *chatRepository) CreateRoom(room *model.Room) error {
 	return r.DB.Create(room).Error
 }
 
+// CreateRoomParticipant adds a user to a room, relying on the unique index of
+// room_participants so concurrent joins cannot add the same user twice
 func (r *chatRepository) CreateRoomParticipant(roomParticipant *model.RoomParticipant) error {
-	return r.DB.Create(roomParticipant).Error
+	result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(roomParticipant)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAlreadyParticipant
+	}
+	return nil
 }
 
 func (r *chatRepository) SaveMessage(message *model.Message) error {
 	return r.DB.Create(message).Error
 }
 
+// SaveMessages creates several messages at once, all or none
+func (r *chatRepository) SaveMessages(messages []model.Message) error {
+	return r.DB.Create(&messages).Error
+}
+
 func (r *chatRepository) GetRoomParticipants(roomID uint) ([]model.RoomParticipant, error) {
 	var participants []model.RoomParticipant
 	if err := r.DB.Where("room_id = ?", roomID).Find(&participants).Error; err != nil {
@@ -62,17 +120,146 @@ func (r *chatRepository) GetRoomParticipants(roomID uint) ([]model.RoomParticipa
 	return participants, nil
 }
 
-func (r *chatRepository) GetRoomMessages(roomID uint, limit int, offset int) (*model.Pagination, error) {
+func orderByCreatedAt(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at")
+}
+
+// roomMessages selects the messages of a room visible to userEmail. Messages deleted
+// for everyone are kept so they can be returned as tombstones.
+func (r *chatRepository) roomMessages(roomID uint, userEmail string) *gorm.DB {
+	return r.DB.Unscoped().Model(&model.Message{}).
+		Where("messages.room_id = ?", roomID).
+		Where("NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.message_id = messages.id AND h.user_email = ? AND h.deleted_at IS NULL)", userEmail).
+		Scopes(notExpired)
+}
+
+// notExpired skips messages past their expiry the sweeper has not deleted yet
+func notExpired(db *gorm.DB) *gorm.DB {
+	return db.Where("(messages.expires_at IS NULL OR messages.expires_at > ?)", time.Now())
+}
+
+// historyOrder sorts room history newest first, consistently with MessageCursor
+const historyOrder = "messages.created_at desc, messages.id desc"
+
+func (r *chatRepository) GetRoomMessages(roomID uint, userEmail string, limit int, offset int) (*model.Pagination, error) {
+	query := func() *gorm.DB {
+		return r.roomMessages(roomID, userEmail)
+	}
+	pagination, err := r.pageMessages(query, userEmail, historyOrder, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// cursors let clients switch from pages to keyset pagination
+	if n := len(pagination.Messages); n > 0 {
+		if pagination.Page < pagination.TotalPages {
+			pagination.NextCursor = model.CursorOf(pagination.Messages[n-1]).Encode()
+		}
+		if offset > 0 {
+			pagination.PrevCursor = model.CursorOf(pagination.Messages[0]).Encode()
+		}
+	}
+	return pagination, nil
+}
+
+// GetRoomMessagesByCursor loads up to limit messages older or newer than the
+// cursor, newest first. Unlike GetRoomMessages it neither counts nor skips rows.
+func (r *chatRepository) GetRoomMessagesByCursor(roomID uint, userEmail string, limit int, cursor *model.MessageCursor, older bool) (*model.Pagination, error) {
+	query := r.roomMessages(roomID, userEmail).Scopes(withMessageDetails)
+	if older {
+		query = query.Where("(messages.created_at, messages.id) < (?, ?)", cursor.CreatedAt, cursor.ID).
+			Order(historyOrder)
+	} else {
+		query = query.Where("(messages.created_at, messages.id) > (?, ?)", cursor.CreatedAt, cursor.ID).
+			Order("messages.created_at asc, messages.id asc")
+	}
+
+	// one extra row tells whether there is more to fetch
+	var messages []model.Message
+	if err := query.Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	if !older {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+
+	if err := r.attachThreadSummaries(messages, userEmail); err != nil {
+		return nil, err
+	}
+
+	pagination := &model.Pagination{
+		Limit:    limit,
+		Messages: messages,
+	}
+	if n := len(messages); n > 0 {
+		if !older || hasMore {
+			pagination.NextCursor = model.CursorOf(messages[n-1]).Encode()
+		}
+		if older || hasMore {
+			pagination.PrevCursor = model.CursorOf(messages[0]).Encode()
+		}
+	}
+	return pagination, nil
+}
+
+// GetRoomMessagesAfter loads up to limit messages of a room following the
+// cursor, oldest first, whoever they are hidden for. A nil cursor starts at the
+// beginning of the room.
+func (r *chatRepository) GetRoomMessagesAfter(roomID uint, cursor *model.MessageCursor, limit int) ([]model.Message, error) {
+	query := r.DB.Unscoped().Where("messages.room_id = ?", roomID).Scopes(notExpired)
+	if cursor != nil {
+		query = query.Where("(messages.created_at, messages.id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var messages []model.Message
+	if err := query.Order("messages.created_at asc, messages.id asc").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GetThreadReplies pages through the direct replies of a message, oldest first
+func (r *chatRepository) GetThreadReplies(roomID, rootID uint, userEmail string, limit int, offset int) (*model.Pagination, error) {
+	query := func() *gorm.DB {
+		return r.roomMessages(roomID, userEmail).Where("messages.reply_to = ?", rootID)
+	}
+	return r.pageMessages(query, userEmail, "created_at asc", limit, offset)
+}
+
+func withMessageDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Receipts").Preload("Reactions", orderByCreatedAt).Preload("Mentions", orderByOffset).
+		Preload("Poll").Preload("Poll.Options", orderByPosition).Preload("Poll.Votes").
+		Preload("Previews")
+}
+
+func orderByOffset(db *gorm.DB) *gorm.DB {
+	return db.Order(clause.OrderByColumn{Column: clause.Column{Name: "offset"}})
+}
+
+// pageMessages counts the messages selected by query and loads one page of them
+// as seen by userEmail
+func (r *chatRepository) pageMessages(query func() *gorm.DB, userEmail string, order string, limit int, offset int) (*model.Pagination, error) {
 	var messages []model.Message
 	var totalItems int64
 
-	// Query to get the total count of messages in the room
-	if err := r.DB.Model(&model.Message{}).Where("room_id = ?", roomID).Count(&totalItems).Error; err != nil {
+	// Query to get the total count of messages
+	if err := query().Count(&totalItems).Error; err != nil {
 		return nil, err
 	}
 
 	// Query to get the paginated messages
-	if err := r.DB.Where("room_id = ?", roomID).Order("created_at desc").Limit(limit).Offset(offset).Find(&messages).Error; err != nil {
+	if err := query().Scopes(withMessageDetails).Order(order).Limit(limit).Offset(offset).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.attachThreadSummaries(messages, userEmail); err != nil {
 		return nil, err
 	}
 
@@ -94,6 +281,42 @@ func (r *chatRepository) GetRoomMessages(roomID uint, limit int, offset int) (*m
 	return pagination, nil
 }
 
+// attachThreadSummaries fills the reply count and last reply time of each message,
+// counting only the replies userEmail can see
+func (r *chatRepository) attachThreadSummaries(messages []model.Message, userEmail string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+
+	var summaries []model.ThreadSummary
+	if err := r.DB.Model(&model.Message{}).
+		Select("reply_to AS message_id, COUNT(*) AS reply_count, MAX(created_at) AS last_reply_at").
+		Where("reply_to IN ?", ids).
+		Where("NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.message_id = messages.id AND h.user_email = ? AND h.deleted_at IS NULL)", userEmail).
+		Scopes(notExpired).
+		Group("reply_to").
+		Scan(&summaries).Error; err != nil {
+		return err
+	}
+
+	byMessage := make(map[uint]model.ThreadSummary, len(summaries))
+	for _, summary := range summaries {
+		byMessage[summary.MessageID] = summary
+	}
+	for i := range messages {
+		if summary, ok := byMessage[messages[i].ID]; ok {
+			messages[i].ReplyCount = summary.ReplyCount
+			messages[i].LastReplyAt = summary.LastReplyAt
+		}
+	}
+	return nil
+}
+
 // func (r *chatRepository) GetRoomMessages(roomID uint, limit int, offset int) ([]model.Message, error) {
 // 	var messages []model.Message
 // 	if err := r.DB.Where("room_id = ?", roomID).Order("created_at desc").Limit(limit).Offset(offset).Find(&messages).Error; err != nil {
@@ -109,3 +332,503 @@ func (r *chatRepository) GetRoomByID(roomID uint) (*model.Room, error) {
 	}
 	return &room, nil
 }
+
+func (r *chatRepository) GetMessageByID(messageID uint) (*model.Message, error) {
+	var message model.Message
+	if err := r.DB.Scopes(notExpired).First(&message, messageID).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// GetMessageDetails loads a message with its receipts, reactions and thread
+// summary as seen by userEmail, including a message deleted for everyone
+func (r *chatRepository) GetMessageDetails(messageID uint, userEmail string) (*model.Message, error) {
+	var message model.Message
+	if err := r.DB.Unscoped().Scopes(withMessageDetails, notExpired).First(&message, messageID).Error; err != nil {
+		return nil, err
+	}
+	messages := []model.Message{message}
+	if err := r.attachThreadSummaries(messages, userEmail); err != nil {
+		return nil, err
+	}
+	return &messages[0], nil
+}
+
+// EditMessage keeps the current content as a revision before replacing it, along
+// with the mentions found in the new content
+func (r *chatRepository) EditMessage(message *model.Message, content string, mentions []model.Mention) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		revision := &model.MessageRevision{
+			MessageID: message.ID,
+			Content:   message.Content,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("message_id = ?", message.ID).Delete(&model.Mention{}).Error; err != nil {
+			return err
+		}
+		for i := range mentions {
+			mentions[i].MessageID = message.ID
+		}
+		if len(mentions) > 0 {
+			if err := tx.Create(&mentions).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		if err := tx.Model(message).Updates(map[string]interface{}{
+			"content":   content,
+			"edited_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		message.Content = content
+		message.EditedAt = &now
+		message.Mentions = mentions
+		return nil
+	})
+}
+
+// DeleteMessage soft deletes a message and drops its pin, if any
+func (r *chatRepository) DeleteMessage(message *model.Message) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("message_id = ?", message.ID).Delete(&model.PinnedMessage{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(message).Error
+	})
+}
+
+func (r *chatRepository) GetRoomParticipant(roomID uint, userEmail string) (*model.RoomParticipant, error) {
+	var participant model.RoomParticipant
+	if err := r.DB.Where("room_id = ? AND user_email = ?", roomID, userEmail).First(&participant).Error; err != nil {
+		return nil, err
+	}
+	return &participant, nil
+}
+
+func (r *chatRepository) SetParticipantRole(participantID uint, role string) error {
+	return r.DB.Model(&model.RoomParticipant{}).Where("id = ?", participantID).Update("role", role).Error
+}
+
+// DeleteRoomParticipant removes the participant along with its poll votes,
+// which reference it
+func (r *chatRepository) DeleteRoomParticipant(participantID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("room_participant_id = ?", participantID).Delete(&model.PollVote{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.RoomParticipant{}, participantID).Error
+	})
+}
+
+func (r *chatRepository) TransferOwnership(owner, newOwner *model.RoomParticipant) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.RoomParticipant{}).Where("id = ?", owner.ID).Update("role", model.RoleAdmin).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.RoomParticipant{}).Where("id = ?", newOwner.ID).Update("role", model.RoleOwner).Error
+	})
+}
+
+func (r *chatRepository) RenameRoom(roomID uint, name string) error {
+	return r.DB.Model(&model.Room{}).Where("id = ?", roomID).Update("name", name).Error
+}
+
+func (r *chatRepository) HideMessage(messageID uint, userEmail string) error {
+	hidden := &model.HiddenMessage{
+		MessageID: messageID,
+		UserEmail: userEmail,
+	}
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(hidden).Error
+}
+
+func (r *chatRepository) IsRoomParticipant(roomID uint, userEmail string) (bool, error) {
+	var count int64
+	if err := r.DB.Model(&model.RoomParticipant{}).Where("room_id = ? AND user_email = ?", roomID, userEmail).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// MarkRead adds a read receipt for userEmail to every message of the room up to
+// upToMessageID that they did not send and have not read yet. A read message
+// counts as delivered too.
+func (r *chatRepository) MarkRead(roomID uint, userEmail string, upToMessageID uint, readAt time.Time) (int64, error) {
+	result := r.DB.Exec(`
+		INSERT INTO message_receipts (created_at, updated_at, message_id, user_email, delivered_at, read_at)
+		SELECT ?, ?, m.id, ?, ?, ? FROM messages m
+		WHERE m.room_id = ? AND m.id <= ? AND m.sender_email <> ? AND m.deleted_at IS NULL
+		ON CONFLICT (message_id, user_email) DO UPDATE
+		SET read_at = EXCLUDED.read_at, updated_at = EXCLUDED.updated_at,
+			delivered_at = COALESCE(message_receipts.delivered_at, EXCLUDED.delivered_at)
+		WHERE message_receipts.read_at IS NULL`,
+		readAt, readAt, userEmail, readAt, readAt, roomID, upToMessageID, userEmail,
+	)
+	return result.RowsAffected, result.Error
+}
+
+// MarkDelivered records the first time a message reached userEmail and reports
+// whether the receipt is new
+func (r *chatRepository) MarkDelivered(messageID uint, userEmail string, deliveredAt time.Time) (bool, error) {
+	result := r.DB.Exec(`
+		INSERT INTO message_receipts (created_at, updated_at, message_id, user_email, delivered_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (message_id, user_email) DO UPDATE
+		SET delivered_at = EXCLUDED.delivered_at, updated_at = EXCLUDED.updated_at
+		WHERE message_receipts.delivered_at IS NULL`,
+		deliveredAt, deliveredAt, messageID, userEmail, deliveredAt,
+	)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *chatRepository) AddReaction(reaction *model.Reaction) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+}
+
+func (r *chatRepository) RemoveReaction(messageID uint, userEmail, emoji string) error {
+	return r.DB.Unscoped().
+		Where("message_id = ? AND user_email = ? AND emoji = ?", messageID, userEmail, emoji).
+		Delete(&model.Reaction{}).Error
+}
+
+func (r *chatRepository) GetReactions(messageID uint) ([]model.Reaction, error) {
+	var reactions []model.Reaction
+	if err := r.DB.Where("message_id = ?", messageID).Scopes(orderByCreatedAt).Find(&reactions).Error; err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
+
+// PinMessage pins a message unless the room already holds limit pins. The room row
+// is locked so concurrent pins cannot exceed the limit. Pinning a message twice
+// keeps the original pin, which is loaded into pin.
+func (r *chatRepository) PinMessage(pin *model.PinnedMessage, limit int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var room model.Room
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, pin.RoomID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Unscoped().Where("message_id = ?", pin.MessageID).First(pin).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&model.PinnedMessage{}).Unscoped().Where("room_id = ?", pin.RoomID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(limit) {
+			return ErrPinLimitReached
+		}
+		return tx.Create(pin).Error
+	})
+}
+
+// UnpinMessage removes the pin of a message and reports whether there was one
+func (r *chatRepository) UnpinMessage(roomID, messageID uint) (bool, error) {
+	result := r.DB.Unscoped().
+		Where("room_id = ? AND message_id = ?", roomID, messageID).
+		Delete(&model.PinnedMessage{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetPinnedMessages lists the pins of a room, most recently pinned first
+func (r *chatRepository) GetPinnedMessages(roomID uint) ([]model.PinnedMessage, error) {
+	var pins []model.PinnedMessage
+	if err := r.DB.Unscoped().
+		Preload("Message", withMessageDetails).
+		Where("message_id IN (?)", r.DB.Model(&model.Message{}).Select("messages.id").Scopes(notExpired)).
+		Where("room_id = ?", roomID).
+		Order("pinned_at desc").
+		Find(&pins).Error; err != nil {
+		return nil, err
+	}
+	return pins, nil
+}
+
+// messageSearchVector is the indexed text search vector of a message, it has to
+// stay in sync with the index created by the migration
+const messageSearchVector = "to_tsvector('simple', messages.content)"
+
+// messageSearchQuery parses the user's search terms the way web search engines do
+const messageSearchQuery = "websearch_to_tsquery('simple', ?)"
+
+// ts_headline marks the matched terms with these private use characters, which
+// are stripped from the content beforehand. They become <mark> tags once the
+// rest of the headline is HTML escaped, so message content never renders as markup.
+const (
+	highlightStart = "\ue000"
+	highlightStop  = "\ue001"
+)
+
+var highlightMarks = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// messageHighlight is the headline of a message with its matched terms marked
+const messageHighlight = "ts_headline('simple', translate(messages.content, ?, ''), " + messageSearchQuery +
+	", 'StartSel=" + highlightStart + ", StopSel=" + highlightStop + "') AS highlight"
+
+// SearchMessages ranks the messages of the caller's rooms against the search
+// query and highlights the matched terms
+func (r *chatRepository) SearchMessages(search model.MessageSearch, limit int, offset int) (*model.Pagination, error) {
+	query := func() *gorm.DB {
+		db := r.DB.Model(&model.Message{}).
+			Where(messageSearchVector+" @@ "+messageSearchQuery, search.Query).
+			Where("messages.room_id IN (SELECT p.room_id FROM room_participants p WHERE p.user_email = ? AND p.deleted_at IS NULL)", search.UserEmail).
+			Where("NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.message_id = messages.id AND h.user_email = ? AND h.deleted_at IS NULL)", search.UserEmail).
+			Scopes(notExpired)
+		if search.RoomID != 0 {
+			db = db.Where("messages.room_id = ?", search.RoomID)
+		}
+		if search.SenderEmail != "" {
+			db = db.Where("messages.sender_email = ?", search.SenderEmail)
+		}
+		if search.From != nil {
+			db = db.Where("messages.created_at >= ?", *search.From)
+		}
+		if search.To != nil {
+			db = db.Where("messages.created_at <= ?", *search.To)
+		}
+		return db
+	}
+
+	var totalItems int64
+	if err := query().Count(&totalItems).Error; err != nil {
+		return nil, err
+	}
+
+	var messages []model.Message
+	if err := query().
+		Select("messages.*, "+messageHighlight, highlightStart+highlightStop, search.Query).
+		Scopes(withMessageDetails).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(" + messageSearchVector + ", " + messageSearchQuery + ") DESC, messages.created_at DESC",
+			Vars:               []interface{}{search.Query},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).Offset(offset).
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range messages {
+		messages[i].Highlight = highlightMarks.Replace(html.EscapeString(messages[i].Highlight))
+	}
+
+	if err := r.attachThreadSummaries(messages, search.UserEmail); err != nil {
+		return nil, err
+	}
+
+	totalPages := int(totalItems) / limit
+	if totalItems%int64(limit) != 0 {
+		totalPages++
+	}
+
+	return &model.Pagination{
+		Page:       offset/limit + 1,
+		Limit:      limit,
+		TotalItems: int(totalItems),
+		TotalPages: totalPages,
+		Messages:   messages,
+	}, nil
+}
+
+// GetUserRooms summarizes every room of userEmail with its latest visible message
+// and unread count, most recently active room first
+func (r *chatRepository) GetUserRooms(userEmail string) ([]model.RoomSummary, error) {
+	var rooms []model.RoomSummary
+	err := r.DB.Raw(`
+		SELECT r.id AS room_id, r.name AS room_name,
+			lm.id AS last_message_id, lm.sender_email AS last_message_sender,
+			lm.content AS last_message_text, lm.created_at AS last_message_at,
+			COALESCE(lm.created_at, r.created_at) AS last_activity_at,
+			(SELECT COUNT(*) FROM messages m
+				WHERE m.room_id = r.id AND m.deleted_at IS NULL AND m.sender_email <> @email
+				AND (m.expires_at IS NULL OR m.expires_at > @now)
+				AND NOT EXISTS (SELECT 1 FROM message_receipts mr WHERE mr.message_id = m.id AND mr.user_email = @email AND mr.read_at IS NOT NULL AND mr.deleted_at IS NULL)
+				AND NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.message_id = m.id AND h.user_email = @email AND h.deleted_at IS NULL)
+			) AS unread_count
+		FROM room_participants p
+		JOIN rooms r ON r.id = p.room_id AND r.deleted_at IS NULL
+		LEFT JOIN LATERAL (
+			SELECT m.id, m.sender_email, m.content, m.created_at FROM messages m
+			WHERE m.room_id = r.id AND m.deleted_at IS NULL
+			AND (m.expires_at IS NULL OR m.expires_at > @now)
+			AND NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.message_id = m.id AND h.user_email = @email AND h.deleted_at IS NULL)
+			ORDER BY m.created_at DESC, m.id DESC
+			LIMIT 1
+		) lm ON true
+		WHERE p.user_email = @email AND p.deleted_at IS NULL
+		ORDER BY last_activity_at DESC, r.id DESC`,
+		sql.Named("email", userEmail), sql.Named("now", time.Now()),
+	).Scan(&rooms).Error
+	if err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+func (r *chatRepository) GetUsersByUsernames(usernames []string) ([]model.User, error) {
+	var users []model.User
+	if err := r.DB.Select("id", "username", "email").Where("LOWER(username) IN ?", lower(usernames)).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetMentions pages through the messages of others that mention userEmail in
+// the rooms they still participate in, newest first
+func (r *chatRepository) GetMentions(userEmail string, limit int, offset int) (*model.Pagination, error) {
+	query := func() *gorm.DB {
+		return r.DB.Model(&model.Message{}).
+			Where("messages.id IN (SELECT mn.message_id FROM mentions mn WHERE mn.user_email = ? AND mn.deleted_at IS NULL)", userEmail).
+			Where("messages.sender_email <> ?", userEmail).
+			Where("messages.room_id IN (SELECT p.room_id FROM room_participants p WHERE p.user_email = ? AND p.deleted_at IS NULL)", userEmail).
+			Where("NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.message_id = messages.id AND h.user_email = ? AND h.deleted_at IS NULL)", userEmail).
+			Scopes(notExpired)
+	}
+	return r.pageMessages(query, userEmail, historyOrder, limit, offset)
+}
+
+func lower(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+	return lowered
+}
+
+func (r *chatRepository) SetRoomRetention(roomID uint, retention string) error {
+	return r.DB.Model(&model.Room{}).Where("id = ?", roomID).Update("retention", retention).Error
+}
+
+// DeleteExpiredMessages hard deletes up to limit expired messages with everything
+// attached to them. It returns the attachment URLs of the deleted messages, which
+// the caller has to remove from storage, and how many messages were deleted.
+func (r *chatRepository) DeleteExpiredMessages(now time.Time, limit int) ([]string, int, error) {
+	var attachments []string
+	var deleted int
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var expired []model.Message
+		if err := tx.Unscoped().
+			Select("id", "attachment_url").
+			Where("expires_at <= ?", now).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Limit(limit).
+			Find(&expired).Error; err != nil {
+			return err
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(expired))
+		for i, m := range expired {
+			ids[i] = m.ID
+			if m.AttachmentURL != nil && *m.AttachmentURL != "" {
+				attachments = append(attachments, *m.AttachmentURL)
+			}
+		}
+
+		var polls []uint
+		if err := tx.Unscoped().Model(&model.Poll{}).Where("message_id IN ?", ids).Pluck("id", &polls).Error; err != nil {
+			return err
+		}
+		if len(polls) > 0 {
+			for _, dependent := range []interface{}{&model.PollVote{}, &model.PollOption{}} {
+				if err := tx.Unscoped().Where("poll_id IN ?", polls).Delete(dependent).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, dependent := range []interface{}{
+			&model.Poll{},
+			&model.LinkPreview{},
+			&model.MessageReceipt{},
+			&model.Reaction{},
+			&model.Mention{},
+			&model.PinnedMessage{},
+			&model.HiddenMessage{},
+			&model.MessageRevision{},
+		} {
+			if err := tx.Unscoped().Where("message_id IN ?", ids).Delete(dependent).Error; err != nil {
+				return err
+			}
+		}
+
+		// what only points at the messages outlives them
+		for _, column := range []string{"reply_to", "forwarded_from"} {
+			if err := tx.Unscoped().Model(&model.Message{}).
+				Where(column+" IN ?", ids).
+				UpdateColumn(column, nil).Error; err != nil {
+				return err
+			}
+		}
+		for _, column := range []string{"reply_to", "message_id"} {
+			if err := tx.Unscoped().Model(&model.ScheduledMessage{}).
+				Where(column+" IN ?", ids).
+				UpdateColumn(column, nil).Error; err != nil {
+				return err
+			}
+		}
+
+		// queued deliveries would post the content after it expired
+		if err := tx.Unscoped().
+			Where("status = ?", model.DeliveryStatusPending).
+			Where("(payload->'message'->>'id')::bigint IN ?", ids).
+			Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&model.Message{}).Error; err != nil {
+			return err
+		}
+		deleted = len(ids)
+
+		// forwarded copies share the attachment of their original
+		if len(attachments) > 0 {
+			var shared []string
+			if err := tx.Unscoped().Model(&model.Message{}).
+				Where("attachment_url IN ?", attachments).
+				Distinct().Pluck("attachment_url", &shared).Error; err != nil {
+				return err
+			}
+			attachments = without(attachments, shared)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return attachments, deleted, nil
+}
+
+func (r *chatRepository) SaveLinkPreviews(previews []model.LinkPreview) error {
+	return r.DB.Create(&previews).Error
+}
+
+// without returns the values not present in exclude
+func without(values, exclude []string) []string {
+	excluded := make(map[string]bool, len(exclude))
+	for _, v := range exclude {
+		excluded[v] = true
+	}
+	var kept []string
+	for _, v := range values {
+		if !excluded[v] {
+			kept = append(kept, v)
+			excluded[v] = true
+		}
+	}
+	return kept
+}
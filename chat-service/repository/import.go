@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"project/chat-service/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// importBatchSize is how many messages are inserted per statement
+const importBatchSize = 500
+
+type ImportRepository interface {
+	GetImport(userEmail, checksum string) (*model.ChatImport, error)
+	CreateImport(chatImport *model.ChatImport) error
+	UpdateImportError(id uint, lastError string) error
+	ImportRoom(chatImport *model.ChatImport, room *model.Room, participants []model.RoomParticipant, invites []model.RoomInvite, messages []model.Message, replies map[int]int) error
+}
+
+type importRepository struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewImportRepository(db *gorm.DB, log *zap.Logger) ImportRepository {
+	return &importRepository{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *importRepository) GetImport(userEmail, checksum string) (*model.ChatImport, error) {
+	var chatImport model.ChatImport
+	if err := r.DB.Where("user_email = ? AND checksum = ?", userEmail, checksum).First(&chatImport).Error; err != nil {
+		return nil, err
+	}
+	return &chatImport, nil
+}
+
+func (r *importRepository) CreateImport(chatImport *model.ChatImport) error {
+	return r.DB.Create(chatImport).Error
+}
+
+func (r *importRepository) UpdateImportError(id uint, lastError string) error {
+	return r.DB.Model(&model.ChatImport{}).Where("id = ?", id).Update("last_error", lastError).Error
+}
+
+// ImportRoom creates the room with its participants, invites and messages and
+// completes the import, all or none. replies maps the index of a message to the
+// index of the message it answers.
+func (r *importRepository) ImportRoom(chatImport *model.ChatImport, room *model.Room, participants []model.RoomParticipant, invites []model.RoomInvite, messages []model.Message, replies map[int]int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(room).Error; err != nil {
+			return err
+		}
+
+		for i := range participants {
+			participants[i].RoomID = room.ID
+		}
+		if len(participants) > 0 {
+			if err := tx.Create(&participants).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range invites {
+			invites[i].RoomID = room.ID
+		}
+		if len(invites) > 0 {
+			if err := tx.Create(&invites).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range messages {
+			messages[i].RoomID = room.ID
+		}
+		if len(messages) > 0 {
+			if err := tx.CreateInBatches(&messages, importBatchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		// ids are only known once inserted
+		for i, parent := range replies {
+			if err := tx.Model(&model.Message{}).Where("id = ?", messages[i].ID).
+				Update("reply_to", messages[parent].ID).Error; err != nil {
+				return err
+			}
+			messages[i].ReplyTo = &messages[parent].ID
+		}
+
+		chatImport.RoomID = &room.ID
+		chatImport.Status = model.ImportStatusCompleted
+		chatImport.LastError = ""
+		return tx.Model(chatImport).
+			Select("room_id", "status", "imported", "skipped", "failed", "last_error").
+			Updates(chatImport).Error
+	})
+}
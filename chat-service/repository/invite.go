@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"project/chat-service/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type InviteRepository interface {
+	CreateInvite(invite *model.RoomInvite) error
+	GetInvites(roomID uint) ([]model.RoomInvite, error)
+	GetInviteByID(id uint) (*model.RoomInvite, error)
+	GetInviteByTokenHash(tokenHash string) (*model.RoomInvite, error)
+	// ConsumeInvite takes one use of the invite, it returns false when the invite
+	// expired at now or has no use left
+	ConsumeInvite(id uint, now time.Time) (bool, error)
+	// ReleaseInvite gives back a use taken by ConsumeInvite
+	ReleaseInvite(id uint) error
+	DeleteInvite(id uint) error
+}
+
+type inviteRepository struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewInviteRepository(db *gorm.DB, log *zap.Logger) InviteRepository {
+	return &inviteRepository{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *inviteRepository) CreateInvite(invite *model.RoomInvite) error {
+	return r.DB.Create(invite).Error
+}
+
+func (r *inviteRepository) GetInvites(roomID uint) ([]model.RoomInvite, error) {
+	var invites []model.RoomInvite
+	if err := r.DB.Where("room_id = ?", roomID).Order("id").Find(&invites).Error; err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+func (r *inviteRepository) GetInviteByID(id uint) (*model.RoomInvite, error) {
+	var invite model.RoomInvite
+	if err := r.DB.First(&invite, id).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func (r *inviteRepository) GetInviteByTokenHash(tokenHash string) (*model.RoomInvite, error) {
+	var invite model.RoomInvite
+	if err := r.DB.Where("token_hash = ?", tokenHash).First(&invite).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ConsumeInvite checks and increments the counter in a single statement, so
+// concurrent accepts never take more uses than the invite allows
+func (r *inviteRepository) ConsumeInvite(id uint, now time.Time) (bool, error) {
+	result := r.DB.Model(&model.RoomInvite{}).
+		Where("id = ?", id).
+		Where("max_uses = 0 OR uses < max_uses").
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *inviteRepository) ReleaseInvite(id uint) error {
+	return r.DB.Model(&model.RoomInvite{}).
+		Where("id = ? AND uses > 0", id).
+		UpdateColumn("uses", gorm.Expr("uses - 1")).Error
+}
+
+// DeleteInvite removes the invite for good so its token hash cannot match again
+func (r *inviteRepository) DeleteInvite(id uint) error {
+	return r.DB.Unscoped().Delete(&model.RoomInvite{}, id).Error
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"errors"
+	"project/chat-service/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PollRepository interface {
+	GetPollByID(pollID uint) (*model.Poll, error)
+	ReplaceVotes(pollID uint, participant *model.RoomParticipant, optionIDs []uint) error
+	DeleteVotes(pollID uint, participantID uint) (bool, error)
+	ClosePoll(pollID uint, closedAt time.Time) (bool, error)
+}
+
+// ErrPollClosed is returned when voting on a poll that no longer accepts votes
+var ErrPollClosed = errors.New("poll is closed")
+
+type pollRepository struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewPollRepository(db *gorm.DB, log *zap.Logger) PollRepository {
+	return &pollRepository{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func orderByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+// GetPollByID loads a poll with its options and votes
+func (r *pollRepository) GetPollByID(pollID uint) (*model.Poll, error) {
+	var poll model.Poll
+	if err := r.DB.Preload("Options", orderByPosition).Preload("Votes").First(&poll, pollID).Error; err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+// ReplaceVotes sets the votes of a participant to exactly optionIDs. Polls closed
+// in the meantime are left untouched.
+func (r *pollRepository) ReplaceVotes(pollID uint, participant *model.RoomParticipant, optionIDs []uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// lock the poll so concurrent votes of a participant and closing are serialized
+		var poll model.Poll
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&poll, pollID).Error; err != nil {
+			return err
+		}
+		if poll.ClosedAt != nil {
+			return ErrPollClosed
+		}
+
+		if err := tx.Unscoped().
+			Where("poll_id = ? AND room_participant_id = ?", pollID, participant.ID).
+			Delete(&model.PollVote{}).Error; err != nil {
+			return err
+		}
+
+		votes := make([]model.PollVote, len(optionIDs))
+		for i, optionID := range optionIDs {
+			votes[i] = model.PollVote{
+				PollID:            pollID,
+				OptionID:          optionID,
+				RoomParticipantID: participant.ID,
+				UserEmail:         participant.UserEmail,
+			}
+		}
+		return tx.Omit("RoomParticipant").Create(&votes).Error
+	})
+}
+
+// DeleteVotes removes every vote of a participant and reports whether there was any
+func (r *pollRepository) DeleteVotes(pollID uint, participantID uint) (bool, error) {
+	var deleted int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var poll model.Poll
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&poll, pollID).Error; err != nil {
+			return err
+		}
+		if poll.ClosedAt != nil {
+			return ErrPollClosed
+		}
+
+		result := tx.Unscoped().
+			Where("poll_id = ? AND room_participant_id = ?", pollID, participantID).
+			Delete(&model.PollVote{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted > 0, err
+}
+
+// ClosePoll stops accepting votes and reports whether the poll was still open
+func (r *pollRepository) ClosePoll(pollID uint, closedAt time.Time) (bool, error) {
+	result := r.DB.Model(&model.Poll{}).
+		Where("id = ? AND closed_at IS NULL", pollID).
+		Update("closed_at", closedAt)
+	return result.RowsAffected > 0, result.Error
+}
@@ -6,11 +6,23 @@ import (
 )
 
 type Repository struct {
-	ChatRepo ChatRepository
+	ChatRepo     ChatRepository
+	ScheduleRepo ScheduleRepository
+	PollRepo     PollRepository
+	BotRepo      BotRepository
+	WebhookRepo  WebhookRepository
+	ImportRepo   ImportRepository
+	InviteRepo   InviteRepository
 }
 
 func NewRepository(db *gorm.DB, log *zap.Logger) Repository {
 	return Repository{
-		ChatRepo: NewChatRepository(db, log),
+		ChatRepo:     NewChatRepository(db, log),
+		ScheduleRepo: NewScheduleRepository(db, log),
+		PollRepo:     NewPollRepository(db, log),
+		BotRepo:      NewBotRepository(db, log),
+		WebhookRepo:  NewWebhookRepository(db, log),
+		ImportRepo:   NewImportRepository(db, log),
+		InviteRepo:   NewInviteRepository(db, log),
 	}
 }
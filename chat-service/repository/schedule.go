@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"project/chat-service/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ScheduleRepository interface {
+	CreateScheduledMessage(scheduled *model.ScheduledMessage) error
+	GetScheduledMessageByID(id uint) (*model.ScheduledMessage, error)
+	GetScheduledMessages(senderEmail string, roomID uint) ([]model.ScheduledMessage, error)
+	CancelScheduledMessage(id uint) (bool, error)
+	ClaimDueScheduledMessages(now time.Time, limit int) ([]model.ScheduledMessage, error)
+	FinishScheduledMessage(id uint, messageID *uint, sendErr error) error
+	FailStaleScheduledMessages(claimedBefore time.Time, reason error) (int64, error)
+}
+
+type scheduleRepository struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewScheduleRepository(db *gorm.DB, log *zap.Logger) ScheduleRepository {
+	return &scheduleRepository{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *scheduleRepository) CreateScheduledMessage(scheduled *model.ScheduledMessage) error {
+	return r.DB.Create(scheduled).Error
+}
+
+func (r *scheduleRepository) GetScheduledMessageByID(id uint) (*model.ScheduledMessage, error) {
+	var scheduled model.ScheduledMessage
+	if err := r.DB.First(&scheduled, id).Error; err != nil {
+		return nil, err
+	}
+	return &scheduled, nil
+}
+
+// GetScheduledMessages lists the messages senderEmail scheduled, in one room when
+// roomID is set, soonest first
+func (r *scheduleRepository) GetScheduledMessages(senderEmail string, roomID uint) ([]model.ScheduledMessage, error) {
+	var scheduled []model.ScheduledMessage
+	query := r.DB.Where("sender_email = ?", senderEmail)
+	if roomID != 0 {
+		query = query.Where("room_id = ?", roomID)
+	}
+	if err := query.Order("send_at").Find(&scheduled).Error; err != nil {
+		return nil, err
+	}
+	return scheduled, nil
+}
+
+// CancelScheduledMessage cancels a message that is still pending and reports
+// whether it was
+func (r *scheduleRepository) CancelScheduledMessage(id uint) (bool, error) {
+	result := r.DB.Model(&model.ScheduledMessage{}).
+		Where("id = ? AND status = ?", id, model.ScheduledStatusPending).
+		Update("status", model.ScheduledStatusCanceled)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClaimDueScheduledMessages moves up to limit due messages from pending to
+// sending and returns them. Rows locked by another replica are skipped, so each
+// message is claimed exactly once.
+func (r *scheduleRepository) ClaimDueScheduledMessages(now time.Time, limit int) ([]model.ScheduledMessage, error) {
+	var claimed []model.ScheduledMessage
+	err := r.DB.Raw(`
+		UPDATE scheduled_messages SET status = ?, claimed_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM scheduled_messages
+			WHERE status = ? AND send_at <= ? AND deleted_at IS NULL
+			ORDER BY send_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		model.ScheduledStatusSending, now, now,
+		model.ScheduledStatusPending, now, limit,
+	).Scan(&claimed).Error
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// FinishScheduledMessage records the outcome of sending a claimed message
+func (r *scheduleRepository) FinishScheduledMessage(id uint, messageID *uint, sendErr error) error {
+	updates := map[string]interface{}{
+		"status":     model.ScheduledStatusSent,
+		"message_id": messageID,
+	}
+	if sendErr != nil {
+		updates["status"] = model.ScheduledStatusFailed
+		updates["error"] = sendErr.Error()
+	}
+	return r.DB.Model(&model.ScheduledMessage{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// FailStaleScheduledMessages marks failed the messages claimed before
+// claimedBefore that never finished sending and returns how many there were
+func (r *scheduleRepository) FailStaleScheduledMessages(claimedBefore time.Time, reason error) (int64, error) {
+	result := r.DB.Model(&model.ScheduledMessage{}).
+		Where("status = ? AND claimed_at < ?", model.ScheduledStatusSending, claimedBefore).
+		Updates(map[string]interface{}{
+			"status": model.ScheduledStatusFailed,
+			"error":  reason.Error(),
+		})
+	return result.RowsAffected, result.Error
+}
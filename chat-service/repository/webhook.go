@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"project/chat-service/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	CreateWebhook(webhook *model.RoomWebhook) error
+	GetWebhooks(roomID uint) ([]model.RoomWebhook, error)
+	GetWebhookByID(id uint) (*model.RoomWebhook, error)
+	DeleteWebhook(id uint) error
+	CreateDeliveries(deliveries []model.WebhookDelivery) error
+	GetDelivery(id uint) (*model.WebhookDelivery, error)
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	GetDeliveries(webhookID uint, limit int, offset int) ([]model.WebhookDelivery, int64, error)
+	CreateIncomingWebhook(hook *model.IncomingWebhook) error
+	GetIncomingWebhooks(roomID uint) ([]model.IncomingWebhook, error)
+	GetIncomingWebhookByID(id uint) (*model.IncomingWebhook, error)
+	GetIncomingWebhookByTokenHash(tokenHash string) (*model.IncomingWebhook, error)
+	UpdateIncomingWebhookToken(id uint, tokenHash string) error
+	DeleteIncomingWebhook(id uint) error
+}
+
+type webhookRepository struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewWebhookRepository(db *gorm.DB, log *zap.Logger) WebhookRepository {
+	return &webhookRepository{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (r *webhookRepository) CreateWebhook(webhook *model.RoomWebhook) error {
+	return r.DB.Create(webhook).Error
+}
+
+func (r *webhookRepository) GetWebhooks(roomID uint) ([]model.RoomWebhook, error) {
+	var webhooks []model.RoomWebhook
+	if err := r.DB.Where("room_id = ?", roomID).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) GetWebhookByID(id uint) (*model.RoomWebhook, error) {
+	var webhook model.RoomWebhook
+	if err := r.DB.First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) DeleteWebhook(id uint) error {
+	return r.DB.Delete(&model.RoomWebhook{}, id).Error
+}
+
+func (r *webhookRepository) CreateDeliveries(deliveries []model.WebhookDelivery) error {
+	return r.DB.Create(&deliveries).Error
+}
+
+func (r *webhookRepository) GetDelivery(id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := r.DB.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// UpdateDelivery records the outcome of an attempt
+func (r *webhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return r.DB.Model(delivery).Select(
+		"status", "attempts", "last_status_code", "last_error", "next_attempt_at", "delivered_at",
+	).Updates(delivery).Error
+}
+
+// GetDeliveries lists the deliveries of a webhook, newest first
+func (r *webhookRepository) GetDeliveries(webhookID uint, limit int, offset int) ([]model.WebhookDelivery, int64, error) {
+	var deliveries []model.WebhookDelivery
+	var total int64
+	query := r.DB.Model(&model.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("id desc").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (r *webhookRepository) CreateIncomingWebhook(hook *model.IncomingWebhook) error {
+	return r.DB.Create(hook).Error
+}
+
+func (r *webhookRepository) GetIncomingWebhooks(roomID uint) ([]model.IncomingWebhook, error) {
+	var hooks []model.IncomingWebhook
+	if err := r.DB.Where("room_id = ?", roomID).Order("id").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *webhookRepository) GetIncomingWebhookByID(id uint) (*model.IncomingWebhook, error) {
+	var hook model.IncomingWebhook
+	if err := r.DB.First(&hook, id).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepository) GetIncomingWebhookByTokenHash(tokenHash string) (*model.IncomingWebhook, error) {
+	var hook model.IncomingWebhook
+	if err := r.DB.Where("token_hash = ?", tokenHash).First(&hook).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepository) UpdateIncomingWebhookToken(id uint, tokenHash string) error {
+	return r.DB.Model(&model.IncomingWebhook{}).Where("id = ?", id).Update("token_hash", tokenHash).Error
+}
+
+// DeleteIncomingWebhook removes the webhook for good so its token hash cannot
+// match again
+func (r *webhookRepository) DeleteIncomingWebhook(id uint) error {
+	return r.DB.Unscoped().Delete(&model.IncomingWebhook{}, id).Error
+}
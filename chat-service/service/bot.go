@@ -0,0 +1,469 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"project/chat-service/database"
+	"project/chat-service/model"
+	"project/chat-service/realtime"
+	"project/chat-service/repository"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidBotName    = errors.New("bot name must be 3 to 32 lowercase letters, digits or underscores")
+	ErrInvalidCommand    = errors.New("command must be 1 to 32 lowercase letters, digits or underscores")
+	ErrCommandTaken      = repository.ErrCommandTaken
+	ErrBotNameTaken      = repository.ErrBotNameTaken
+	ErrInvalidBotToken   = errors.New("invalid bot token")
+	ErrInvocationExpired = errors.New("command invocation expired")
+	ErrEmptyBotMessage   = errors.New("bot message must not be empty")
+	ErrBotNotInstalled   = errors.New("bot is not installed in the room")
+)
+
+const (
+	// botReplyWindow is how long a bot may answer a command
+	botReplyWindow = 15 * time.Minute
+	// maxReminderDelay bounds /remind
+	maxReminderDelay = 7 * 24 * time.Hour
+)
+
+var (
+	botNamePattern = regexp.MustCompile(`^[a-z0-9_]{3,32}$`)
+	commandPattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+	// commandLine matches a message running a command, the arguments may span lines
+	commandLine = regexp.MustCompile(`(?s)^/([a-z0-9_]{1,32})(?:\s+(.*))?$`)
+)
+
+// BotService runs the slash commands sent to rooms, either built in or
+// forwarded to the bot that registered them when it is installed in the room
+type BotService interface {
+	// HandleCommand runs the command a message holds and returns its name, or
+	// an empty name when the message is not a known command and must be stored
+	HandleCommand(message *model.Message) (string, error)
+	CreateBot(name, ownerEmail string) (*model.Bot, string, error)
+	// InstallBot lets a bot answer its commands in a room, userEmail must be a
+	// room admin
+	InstallBot(roomID uint, name, userEmail string) (*model.Bot, error)
+	UninstallBot(roomID uint, name, userEmail string) (*model.Bot, error)
+	RegisterBot(token string, commands []model.BotCommand) (*model.Bot, error)
+	// ListenCommands hands every command sent to the bot to send until ctx is done
+	ListenCommands(ctx context.Context, token string, send func(model.BotInvocation) error) error
+	PostBotMessage(token string, invocationID uint, content string) (*model.Message, error)
+}
+
+// commandCall is one run of a command
+type commandCall struct {
+	Name      string
+	Args      string
+	RoomID    uint
+	UserEmail string
+}
+
+type builtinCommand struct {
+	description string
+	run         func(call commandCall) error
+}
+
+type botService struct {
+	repo      repository.Repository
+	chat      ChatService
+	polls     PollService
+	schedule  ScheduleService
+	publisher Publisher
+	rdb       *database.Cacher
+	builtins  map[string]builtinCommand
+	log       *zap.Logger
+}
+
+func NewBotService(repo repository.Repository, chat ChatService, polls PollService, schedule ScheduleService, publisher Publisher, rdb *database.Cacher, log *zap.Logger) BotService {
+	s := &botService{repo: repo, chat: chat, polls: polls, schedule: schedule, publisher: publisher, rdb: rdb, log: log}
+	s.builtins = map[string]builtinCommand{
+		"help":   {description: "/help lists the available commands", run: s.runHelp},
+		"poll":   {description: `/poll "question" "option" "option"... posts a poll`, run: s.runPoll},
+		"remind": {description: "/remind 30m text posts a reminder to the room later", run: s.runRemind},
+		"giphy":  {description: "/giphy words shares a GIF search", run: s.runGiphy},
+	}
+	return s
+}
+
+func (s *botService) HandleCommand(message *model.Message) (string, error) {
+	if message.Type != "" && message.Type != model.MessageTypeText {
+		return "", nil
+	}
+	match := commandLine.FindStringSubmatch(message.Content)
+	if match == nil {
+		return "", nil
+	}
+	call := commandCall{
+		Name:      match[1],
+		Args:      strings.TrimSpace(match[2]),
+		RoomID:    message.RoomID,
+		UserEmail: message.SenderEmail,
+	}
+
+	builtin, isBuiltin := s.builtins[call.Name]
+	var bot *model.Bot
+	if !isBuiltin {
+		var err error
+		_, bot, err = s.repo.BotRepo.GetBotCommand(call.Name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		// a bot only hears the rooms it was installed in
+		installed, err := s.repo.BotRepo.IsBotInstalled(call.RoomID, bot.ID)
+		if err != nil {
+			return "", err
+		}
+		if !installed {
+			return "", nil
+		}
+	}
+
+	ok, err := s.repo.ChatRepo.IsRoomParticipant(call.RoomID, call.UserEmail)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrNotParticipant
+	}
+
+	if isBuiltin {
+		return call.Name, builtin.run(call)
+	}
+	return call.Name, s.invoke(bot, call)
+}
+
+// invoke hands a command to its bot, which answers with PostBotMessage
+func (s *botService) invoke(bot *model.Bot, call commandCall) error {
+	invocation := &model.BotInvocation{
+		BotID:     bot.ID,
+		RoomID:    call.RoomID,
+		UserEmail: call.UserEmail,
+		Command:   call.Name,
+		Args:      call.Args,
+		ExpiresAt: time.Now().Add(botReplyWindow),
+	}
+	if err := s.repo.BotRepo.CreateInvocation(invocation); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(invocation)
+	if err != nil {
+		return err
+	}
+	// no subscriber means the bot is not listening
+	receivers, err := s.rdb.PublishCount(model.BotChannel(bot.ID), string(data))
+	if err != nil || receivers == 0 {
+		s.log.Warn("bot did not receive command", zap.String("bot", bot.Name), zap.Int64("receivers", receivers), zap.Error(err))
+		s.reply(call, fmt.Sprintf("%s did not receive the command, try again later", bot.Name))
+	}
+	return nil
+}
+
+// CreateBot registers a bot and returns its token, which is not stored and
+// cannot be shown again
+func (s *botService) CreateBot(name, ownerEmail string) (*model.Bot, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !botNamePattern.MatchString(name) {
+		return nil, "", ErrInvalidBotName
+	}
+	// built in commands post under their own name
+	if _, ok := s.builtins[name]; ok {
+		return nil, "", ErrBotNameTaken
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+	bot := &model.Bot{
+		Name:       name,
+		TokenHash:  hashToken(token),
+		OwnerEmail: ownerEmail,
+	}
+	if err := s.repo.BotRepo.CreateBot(bot); err != nil {
+		return nil, "", err
+	}
+	return bot, token, nil
+}
+
+func (s *botService) InstallBot(roomID uint, name, userEmail string) (*model.Bot, error) {
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+	bot, err := s.repo.BotRepo.GetBotByName(strings.ToLower(strings.TrimSpace(name)))
+	if err != nil {
+		return nil, err
+	}
+
+	install := &model.BotInstall{
+		RoomID:      roomID,
+		BotID:       bot.ID,
+		InstalledBy: userEmail,
+	}
+	if err := s.repo.BotRepo.InstallBot(install); err != nil {
+		return nil, err
+	}
+	return bot, nil
+}
+
+func (s *botService) UninstallBot(roomID uint, name, userEmail string) (*model.Bot, error) {
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+	bot, err := s.repo.BotRepo.GetBotByName(strings.ToLower(strings.TrimSpace(name)))
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := s.repo.BotRepo.UninstallBot(roomID, bot.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, ErrBotNotInstalled
+	}
+	return bot, nil
+}
+
+// RegisterBot replaces the commands a bot answers
+func (s *botService) RegisterBot(token string, commands []model.BotCommand) (*model.Bot, error) {
+	bot, err := s.authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(commands))
+	registered := make([]model.BotCommand, 0, len(commands))
+	for _, c := range commands {
+		name := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(c.Command)), "/")
+		if !commandPattern.MatchString(name) {
+			return nil, ErrInvalidCommand
+		}
+		if _, ok := s.builtins[name]; ok {
+			return nil, ErrCommandTaken
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		registered = append(registered, model.BotCommand{Command: name, Description: strings.TrimSpace(c.Description)})
+	}
+
+	if err := s.repo.BotRepo.ReplaceBotCommands(bot.ID, registered); err != nil {
+		return nil, err
+	}
+	bot.Commands = registered
+	return bot, nil
+}
+
+func (s *botService) ListenCommands(ctx context.Context, token string, send func(model.BotInvocation) error) error {
+	bot, err := s.authenticate(token)
+	if err != nil {
+		return err
+	}
+
+	for message := range s.rdb.Listen(ctx, model.BotChannel(bot.ID)) {
+		var invocation model.BotInvocation
+		if err := json.Unmarshal([]byte(message.Payload), &invocation); err != nil {
+			s.log.Error("failed to decode command invocation", zap.String("bot", bot.Name), zap.Error(err))
+			continue
+		}
+		if err := send(invocation); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// PostBotMessage posts the answer of a bot to the room a command was run in
+func (s *botService) PostBotMessage(token string, invocationID uint, content string) (*model.Message, error) {
+	bot, err := s.authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyBotMessage
+	}
+
+	invocation, err := s.repo.BotRepo.GetInvocation(invocationID)
+	if err != nil {
+		return nil, err
+	}
+	if invocation.BotID != bot.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if time.Now().After(invocation.ExpiresAt) {
+		return nil, ErrInvocationExpired
+	}
+	// uninstalling a bot also cuts off the answers it still owes
+	installed, err := s.repo.BotRepo.IsBotInstalled(invocation.RoomID, bot.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !installed {
+		return nil, ErrBotNotInstalled
+	}
+	return s.postAs(bot.Name, invocation.RoomID, content)
+}
+
+func (s *botService) postAs(botName string, roomID uint, content string) (*model.Message, error) {
+	message := &model.Message{
+		RoomID:      roomID,
+		SenderEmail: model.BotSender(botName),
+		SenderType:  model.SenderTypeBot,
+		Content:     content,
+	}
+	if err := s.chat.SaveMessage(message); err != nil {
+		return nil, err
+	}
+	if err := s.publisher.PublishMessage(message); err != nil {
+		s.log.Error("failed to publish bot message", zap.String("bot", botName), zap.Error(err))
+	}
+	return message, nil
+}
+
+func (s *botService) authenticate(token string) (*model.Bot, error) {
+	if token == "" {
+		return nil, ErrInvalidBotToken
+	}
+	bot, err := s.repo.BotRepo.GetBotByTokenHash(hashToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidBotToken
+	}
+	return bot, err
+}
+
+// reply shows text to the user who ran a command only
+func (s *botService) reply(call commandCall, text string) {
+	event := realtime.Event{
+		Type:      realtime.EventCommandReply,
+		RoomId:    call.RoomID,
+		Recipient: call.UserEmail,
+		Data:      model.CommandReply{Command: call.Name, Text: text},
+	}
+	if err := s.publisher.PublishEvent(event); err != nil {
+		s.log.Error("failed to publish command reply", zap.String("command", call.Name), zap.Error(err))
+	}
+}
+
+func (s *botService) runHelp(call commandCall) error {
+	lines := make([]string, 0, len(s.builtins))
+	for _, builtin := range s.builtins {
+		lines = append(lines, builtin.description)
+	}
+	sort.Strings(lines)
+
+	commands, err := s.repo.BotRepo.GetBotCommands(call.RoomID)
+	if err != nil {
+		return err
+	}
+	for _, c := range commands {
+		lines = append(lines, strings.TrimSpace("/"+c.Command+" "+c.Description))
+	}
+	s.reply(call, strings.Join(lines, "\n"))
+	return nil
+}
+
+func (s *botService) runPoll(call commandCall) error {
+	args := splitCommandArgs(call.Args)
+	if len(args) < 1+minPollOptions {
+		s.reply(call, s.builtins[call.Name].description)
+		return nil
+	}
+
+	_, err := s.polls.CreatePoll(call.RoomID, call.UserEmail, args[0], args[1:], false, false)
+	if errors.Is(err, ErrInvalidPoll) {
+		s.reply(call, err.Error())
+		return nil
+	}
+	return err
+}
+
+func (s *botService) runRemind(call commandCall) error {
+	delay, text, _ := strings.Cut(call.Args, " ")
+	wait, err := time.ParseDuration(delay)
+	text = strings.TrimSpace(text)
+	if err != nil || wait <= 0 || wait > maxReminderDelay || text == "" {
+		s.reply(call, s.builtins[call.Name].description)
+		return nil
+	}
+
+	reminder := &model.ScheduledMessage{
+		RoomID:      call.RoomID,
+		SenderEmail: call.UserEmail,
+		Content:     "Reminder: " + text,
+		SendAt:      time.Now().Add(wait),
+	}
+	if err := s.schedule.ScheduleMessage(reminder); err != nil {
+		return err
+	}
+	s.reply(call, fmt.Sprintf("Reminder set for %s", reminder.SendAt.UTC().Format(time.RFC3339)))
+	return nil
+}
+
+// runGiphy is a stub until a GIF provider is configured, it shares a search link
+func (s *botService) runGiphy(call commandCall) error {
+	if call.Args == "" {
+		s.reply(call, s.builtins[call.Name].description)
+		return nil
+	}
+	_, err := s.postAs(call.Name, call.RoomID, "https://giphy.com/search/"+url.PathEscape(call.Args))
+	return err
+}
+
+// splitCommandArgs splits arguments on spaces, keeping "quoted text" together
+func splitCommandArgs(args string) []string {
+	var parts []string
+	var current strings.Builder
+	quoted, started := false, false
+	for _, r := range args {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if started {
+				parts = append(parts, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want []string
+	}{
+		{name: "empty", args: "", want: nil},
+		{name: "only spaces", args: "  \t\n ", want: nil},
+		{name: "words", args: "deploy api staging", want: []string{"deploy", "api", "staging"}},
+		{name: "repeated whitespace", args: "  deploy \t api\nstaging  ", want: []string{"deploy", "api", "staging"}},
+		{name: "quoted argument", args: `remind "stand up now" 9am`, want: []string{"remind", "stand up now", "9am"}},
+		{name: "empty quotes", args: `set ""`, want: []string{"set", ""}},
+		{name: "quotes inside a word", args: `name="Ana Maria" done`, want: []string{"name=Ana Maria", "done"}},
+		{name: "unterminated quote", args: `say "hello world`, want: []string{"say", "hello world"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitCommandArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitCommandArgs(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,26 +1,82 @@
 package service
 
 import (
+	"errors"
+	"project/chat-service/config"
 	"project/chat-service/model"
 	"project/chat-service/repository"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrMessageNotInRoom = errors.New("message does not belong to this room")
+	ErrNotMessageSender = errors.New("only the sender can modify this message")
+	ErrNotEditable      = errors.New("only text messages can be edited")
+	ErrNotParticipant   = errors.New("user is not a participant of this room")
+	ErrInvalidEmoji     = errors.New("emoji must be a single non-blank emoji")
+	ErrPinLimitReached  = repository.ErrPinLimitReached
+	ErrNoTargetRooms    = errors.New("at least one target room is required")
+	ErrEmptySearchQuery = errors.New("search query must not be empty")
+	ErrInvalidPayload   = model.ErrInvalidPayload
 )
 
+const defaultPageLimit = 10
+
+// maxPageLimit caps the page size of room history
+const maxPageLimit = 100
+
+// maxSearchLimit caps the page size of a message search
+const maxSearchLimit = 50
+
+// maxEmojiRunes bounds a reaction, long enough for skin tone and ZWJ sequences
+const maxEmojiRunes = 10
+
 type ChatService interface {
 	GetUserDetails(userID uint) (*model.User, error)
 	CreateRoom(room *model.Room) error
 	CreateRoomParticipant(roomParticipant *model.RoomParticipant) error
 	SaveMessage(message *model.Message) error
 	GetRoomParticipants(roomID uint) ([]model.RoomParticipant, error)
-	GetRoomMessages(roomID uint, limit int, page int) (*model.Pagination, error)
+	GetRoomMessages(roomID uint, userEmail string, limit int, page int, before, after *model.MessageCursor) (*model.Pagination, error)
 	GetRoomDetails(roomID uint) (*model.Room, error)
+	EditMessage(roomID, messageID uint, senderEmail, content string) (*model.Message, error)
+	DeleteMessage(roomID, messageID uint, userEmail string, forEveryone bool) error
+	MarkRead(roomID uint, userEmail string, upToMessageID uint) (int64, time.Time, error)
+	MarkDelivered(roomID, messageID uint, userEmail string) (*model.Message, bool, time.Time, error)
+	AddReaction(roomID, messageID uint, userEmail, emoji string) ([]model.ReactionCount, error)
+	RemoveReaction(roomID, messageID uint, userEmail, emoji string) ([]model.ReactionCount, error)
+	GetThread(roomID, messageID uint, userEmail string, limit int, page int) (*model.Message, *model.Pagination, error)
+	PinMessage(roomID, messageID uint, userEmail string) (*model.PinnedMessage, error)
+	UnpinMessage(roomID, messageID uint, userEmail string) (bool, error)
+	GetPinnedMessages(roomID uint, userEmail string) ([]model.PinnedMessage, int, error)
+	ForwardMessage(roomID, messageID uint, userEmail string, targetRoomIDs []uint) ([]model.Message, error)
+	SearchMessages(search model.MessageSearch, limit int, page int) (*model.Pagination, error)
+	ListUserRooms(userEmail string) ([]model.RoomSummary, error)
+	ListMentions(userEmail string, limit int, page int) (*model.Pagination, error)
+	PostSystemMessage(roomID uint, event model.SystemPayload) (*model.Message, error)
+	CheckRoomAdmin(roomID uint, userEmail string) error
+	RenameRoom(roomID uint, userEmail, name string) (*model.Message, error)
+	SetParticipantRole(roomID uint, userEmail, targetEmail, role string) (*model.RoomParticipant, error)
+	RemoveParticipant(roomID uint, userEmail, targetEmail string) (*model.Message, error)
+	LeaveRoom(roomID uint, userEmail string) (*model.Message, error)
+	TransferOwnership(roomID uint, userEmail, targetEmail string) (*model.Message, error)
 }
 
 type chatService struct {
-	repo repository.Repository
+	repo      repository.Repository
+	cfg       config.Config
+	publisher Publisher
+	previews  PreviewService
+	webhooks  WebhookService
+	log       *zap.Logger
 }
 
-func NewChatService(repo repository.Repository) ChatService {
-	return &chatService{repo: repo}
+func NewChatService(repo repository.Repository, cfg config.Config, publisher Publisher, previews PreviewService, webhooks WebhookService, log *zap.Logger) ChatService {
+	return &chatService{repo: repo, cfg: cfg, publisher: publisher, previews: previews, webhooks: webhooks, log: log}
 }
 
 func (s *chatService) GetUserDetails(userID uint) (*model.User, error) {
@@ -35,19 +91,486 @@ func (s *chatService) CreateRoomParticipant(roomParticipant *model.RoomParticipa
 	return s.repo.ChatRepo.CreateRoomParticipant(roomParticipant)
 }
 
+// SaveMessage stores a message together with the participants it mentions,
+// after checking its payload matches its type and that a user sending it is in
+// the room. Links are previewed and room webhooks notified afterwards.
 func (s *chatService) SaveMessage(message *model.Message) error {
-	return s.repo.ChatRepo.SaveMessage(message)
+	if message.Type == "" {
+		message.Type = model.MessageTypeText
+	}
+	if message.SenderType == "" {
+		message.SenderType = model.SenderTypeUser
+	}
+	if err := message.ValidatePayload(); err != nil {
+		return err
+	}
+	// system, bot and webhook senders are not participants
+	if message.SenderType == model.SenderTypeUser {
+		if err := s.checkParticipant(message.RoomID, message.SenderEmail); err != nil {
+			return err
+		}
+	}
+
+	room, err := s.repo.ChatRepo.GetRoomByID(message.RoomID)
+	if err != nil {
+		return err
+	}
+
+	mentions, err := s.resolveMentions(message.RoomID, message.Content)
+	if err != nil {
+		return err
+	}
+	message.Mentions = mentions
+	message.ExpiresAt = room.MessageExpiry(time.Now())
+	if err := s.repo.ChatRepo.SaveMessage(message); err != nil {
+		return err
+	}
+
+	s.previews.Unfurl(message)
+	s.webhooks.Notify(model.WebhookEventMessageCreated, message)
+	return nil
+}
+
+// PostSystemMessage announces an event to the room, with its description as
+// content for clients that do not render system payloads
+func (s *chatService) PostSystemMessage(roomID uint, event model.SystemPayload) (*model.Message, error) {
+	message := &model.Message{
+		RoomID:      roomID,
+		SenderEmail: model.SystemSender,
+		SenderType:  model.SenderTypeSystem,
+		Content:     event.Text(),
+	}
+	if err := message.SetPayload(model.MessageTypeSystem, event); err != nil {
+		return nil, err
+	}
+	if err := s.SaveMessage(message); err != nil {
+		return nil, err
+	}
+	if err := s.publisher.PublishMessage(message); err != nil {
+		s.log.Error("failed to publish system message", zap.Uint("roomId", roomID), zap.String("event", event.Event), zap.Error(err))
+	}
+	return message, nil
 }
 
 func (s *chatService) GetRoomParticipants(roomID uint) ([]model.RoomParticipant, error) {
 	return s.repo.ChatRepo.GetRoomParticipants(roomID)
 }
 
-func (s *chatService) GetRoomMessages(roomID uint, limit int, page int) (*model.Pagination, error) {
+// GetRoomMessages returns room history newest first, either one page or, when a
+// cursor is given, the messages right before or after it
+func (s *chatService) GetRoomMessages(roomID uint, userEmail string, limit int, page int, before, after *model.MessageCursor) (*model.Pagination, error) {
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	switch {
+	case before != nil:
+		return s.repo.ChatRepo.GetRoomMessagesByCursor(roomID, userEmail, limit, before, true)
+	case after != nil:
+		return s.repo.ChatRepo.GetRoomMessagesByCursor(roomID, userEmail, limit, after, false)
+	}
+
+	if page <= 0 {
+		page = 1
+	}
 	offset := (page - 1) * limit
-	return s.repo.ChatRepo.GetRoomMessages(roomID, limit, offset)
+	return s.repo.ChatRepo.GetRoomMessages(roomID, userEmail, limit, offset)
 }
 
 func (s *chatService) GetRoomDetails(roomID uint) (*model.Room, error) {
 	return s.repo.ChatRepo.GetRoomByID(roomID)
 }
+
+// EditMessage replaces the content of a text message, which only its sender may
+// do while still in the room
+func (s *chatService) EditMessage(roomID, messageID uint, senderEmail, content string) (*model.Message, error) {
+	message, err := s.repo.ChatRepo.GetMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.RoomID != roomID {
+		return nil, ErrMessageNotInRoom
+	}
+
+	if message.SenderEmail != senderEmail {
+		return nil, ErrNotMessageSender
+	}
+	// the content of polls and payload messages is derived from what they carry
+	if message.Type != "" && message.Type != model.MessageTypeText {
+		return nil, ErrNotEditable
+	}
+	if err := s.checkParticipant(roomID, senderEmail); err != nil {
+		return nil, err
+	}
+
+	mentions, err := s.resolveMentions(roomID, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ChatRepo.EditMessage(message, content, mentions); err != nil {
+		return nil, err
+	}
+	s.webhooks.Notify(model.WebhookEventMessageEdited, message)
+	return message, nil
+}
+
+// DeleteMessage unsends a message for everyone, which only its sender and the
+// room admins may do, or hides it for userEmail alone
+func (s *chatService) DeleteMessage(roomID, messageID uint, userEmail string, forEveryone bool) error {
+	message, err := s.repo.ChatRepo.GetMessageByID(messageID)
+	if err != nil {
+		return err
+	}
+
+	if message.RoomID != roomID {
+		return ErrMessageNotInRoom
+	}
+
+	if !forEveryone {
+		if err := s.checkParticipant(roomID, userEmail); err != nil {
+			return err
+		}
+		return s.repo.ChatRepo.HideMessage(message.ID, userEmail)
+	}
+
+	if message.SenderEmail != userEmail {
+		if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.ChatRepo.DeleteMessage(message); err != nil {
+		return err
+	}
+	s.webhooks.Notify(model.WebhookEventMessageDeleted, message)
+	return nil
+}
+
+func (s *chatService) MarkRead(roomID uint, userEmail string, upToMessageID uint) (int64, time.Time, error) {
+	readAt := time.Now()
+	if err := s.checkParticipant(roomID, userEmail); err != nil {
+		return 0, readAt, err
+	}
+
+	marked, err := s.repo.ChatRepo.MarkRead(roomID, userEmail, upToMessageID, readAt)
+	return marked, readAt, err
+}
+
+// MarkDelivered records that a message reached one of the other participants
+func (s *chatService) MarkDelivered(roomID, messageID uint, userEmail string) (*model.Message, bool, time.Time, error) {
+	deliveredAt := time.Now()
+	message, err := s.repo.ChatRepo.GetMessageByID(messageID)
+	if err != nil {
+		return nil, false, deliveredAt, err
+	}
+
+	if message.RoomID != roomID {
+		return nil, false, deliveredAt, ErrMessageNotInRoom
+	}
+
+	if message.SenderEmail == userEmail {
+		return message, false, deliveredAt, nil
+	}
+
+	if err := s.checkParticipant(roomID, userEmail); err != nil {
+		return nil, false, deliveredAt, err
+	}
+
+	marked, err := s.repo.ChatRepo.MarkDelivered(message.ID, userEmail, deliveredAt)
+	return message, marked, deliveredAt, err
+}
+
+func (s *chatService) AddReaction(roomID, messageID uint, userEmail, emoji string) ([]model.ReactionCount, error) {
+	if err := s.checkReaction(roomID, messageID, userEmail, emoji); err != nil {
+		return nil, err
+	}
+
+	reaction := &model.Reaction{
+		MessageID: messageID,
+		UserEmail: userEmail,
+		Emoji:     emoji,
+	}
+	if err := s.repo.ChatRepo.AddReaction(reaction); err != nil {
+		return nil, err
+	}
+	return s.countReactions(messageID)
+}
+
+func (s *chatService) RemoveReaction(roomID, messageID uint, userEmail, emoji string) ([]model.ReactionCount, error) {
+	if err := s.checkReaction(roomID, messageID, userEmail, emoji); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ChatRepo.RemoveReaction(messageID, userEmail, emoji); err != nil {
+		return nil, err
+	}
+	return s.countReactions(messageID)
+}
+
+// checkReaction validates the emoji and that userEmail may react to the message
+func (s *chatService) checkReaction(roomID, messageID uint, userEmail, emoji string) error {
+	if emoji == "" || strings.ContainsAny(emoji, " \t\n") || utf8.RuneCountInString(emoji) > maxEmojiRunes {
+		return ErrInvalidEmoji
+	}
+
+	message, err := s.repo.ChatRepo.GetMessageByID(messageID)
+	if err != nil {
+		return err
+	}
+
+	if message.RoomID != roomID {
+		return ErrMessageNotInRoom
+	}
+	return s.checkParticipant(roomID, userEmail)
+}
+
+func (s *chatService) countReactions(messageID uint) ([]model.ReactionCount, error) {
+	reactions, err := s.repo.ChatRepo.GetReactions(messageID)
+	if err != nil {
+		return nil, err
+	}
+	return model.CountReactions(reactions), nil
+}
+
+// GetThread returns a message together with one page of its direct replies
+func (s *chatService) GetThread(roomID, messageID uint, userEmail string, limit int, page int) (*model.Message, *model.Pagination, error) {
+	if err := s.checkParticipant(roomID, userEmail); err != nil {
+		return nil, nil, err
+	}
+
+	root, err := s.repo.ChatRepo.GetMessageDetails(messageID, userEmail)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if root.RoomID != roomID {
+		return nil, nil, ErrMessageNotInRoom
+	}
+
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	replies, err := s.repo.ChatRepo.GetThreadReplies(roomID, root.ID, userEmail, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, replies, nil
+}
+
+// PinMessage pins a message of the room, at most pinLimit per room. Only room
+// admins may pin.
+func (s *chatService) PinMessage(roomID, messageID uint, userEmail string) (*model.PinnedMessage, error) {
+	room, err := s.repo.ChatRepo.GetRoomByID(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.repo.ChatRepo.GetMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.RoomID != roomID {
+		return nil, ErrMessageNotInRoom
+	}
+
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+
+	pin := &model.PinnedMessage{
+		RoomID:    roomID,
+		MessageID: messageID,
+		PinnedBy:  userEmail,
+		PinnedAt:  time.Now(),
+	}
+	if err := s.repo.ChatRepo.PinMessage(pin, s.pinLimit(room)); err != nil {
+		return nil, err
+	}
+	return pin, nil
+}
+
+func (s *chatService) UnpinMessage(roomID, messageID uint, userEmail string) (bool, error) {
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return false, err
+	}
+	return s.repo.ChatRepo.UnpinMessage(roomID, messageID)
+}
+
+// GetPinnedMessages returns the pins of a room together with its pin limit
+func (s *chatService) GetPinnedMessages(roomID uint, userEmail string) ([]model.PinnedMessage, int, error) {
+	if err := s.checkParticipant(roomID, userEmail); err != nil {
+		return nil, 0, err
+	}
+
+	room, err := s.repo.ChatRepo.GetRoomByID(roomID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pins, err := s.repo.ChatRepo.GetPinnedMessages(roomID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pins, s.pinLimit(room), nil
+}
+
+// pinLimit is the room's own pin limit, or the configured default when unset
+func (s *chatService) pinLimit(room *model.Room) int {
+	if room.PinLimit > 0 {
+		return room.PinLimit
+	}
+	return s.cfg.PinLimit
+}
+
+// ForwardMessage copies a message into every target room. The caller must be a
+// participant of the source room and of each target room.
+func (s *chatService) ForwardMessage(roomID, messageID uint, userEmail string, targetRoomIDs []uint) ([]model.Message, error) {
+	if len(targetRoomIDs) == 0 {
+		return nil, ErrNoTargetRooms
+	}
+
+	message, err := s.repo.ChatRepo.GetMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.RoomID != roomID {
+		return nil, ErrMessageNotInRoom
+	}
+
+	if err := s.checkParticipant(roomID, userEmail); err != nil {
+		return nil, err
+	}
+
+	// Forwarding a forwarded message keeps pointing at the original
+	origin, originSender := message.ID, message.SenderEmail
+	if message.ForwardedFrom != nil {
+		origin = *message.ForwardedFrom
+	}
+	if message.ForwardedSender != nil {
+		originSender = *message.ForwardedSender
+	}
+
+	// the poll itself stays in its room and a system event where it happened,
+	// copies only carry their text
+	messageType, payload := message.Type, message.Payload
+	if messageType == model.MessageTypePoll || messageType == model.MessageTypeSystem {
+		messageType, payload = model.MessageTypeText, nil
+	}
+
+	seen := make(map[uint]bool, len(targetRoomIDs))
+	copies := make([]model.Message, 0, len(targetRoomIDs))
+	for _, target := range targetRoomIDs {
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		if err := s.checkParticipant(target, userEmail); err != nil {
+			return nil, err
+		}
+
+		room, err := s.repo.ChatRepo.GetRoomByID(target)
+		if err != nil {
+			return nil, err
+		}
+
+		// mentions resolve against the participants of the target room
+		mentions, err := s.resolveMentions(target, message.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		forwarded := model.Message{
+			RoomID:          target,
+			SenderEmail:     userEmail,
+			Type:            messageType,
+			Content:         message.Content,
+			Payload:         payload,
+			AttachmentURL:   message.AttachmentURL,
+			ForwardedFrom:   &origin,
+			ForwardedSender: &originSender,
+			Mentions:        mentions,
+			ExpiresAt:       room.MessageExpiry(time.Now()),
+		}
+		if err := forwarded.ValidatePayload(); err != nil {
+			return nil, err
+		}
+		copies = append(copies, forwarded)
+	}
+
+	if err := s.repo.ChatRepo.SaveMessages(copies); err != nil {
+		return nil, err
+	}
+	for i := range copies {
+		s.previews.Unfurl(&copies[i])
+		s.webhooks.Notify(model.WebhookEventMessageCreated, &copies[i])
+	}
+	return copies, nil
+}
+
+// SearchMessages looks for messages matching the search query in the rooms the
+// caller participates in
+func (s *chatService) SearchMessages(search model.MessageSearch, limit int, page int) (*model.Pagination, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	if search.Query == "" {
+		return nil, ErrEmptySearchQuery
+	}
+
+	if search.RoomID != 0 {
+		if err := s.checkParticipant(search.RoomID, search.UserEmail); err != nil {
+			return nil, err
+		}
+	}
+
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	return s.repo.ChatRepo.SearchMessages(search, limit, offset)
+}
+
+func (s *chatService) ListUserRooms(userEmail string) ([]model.RoomSummary, error) {
+	return s.repo.ChatRepo.GetUserRooms(userEmail)
+}
+
+// ListMentions returns the messages mentioning userEmail, newest first
+func (s *chatService) ListMentions(userEmail string, limit int, page int) (*model.Pagination, error) {
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	return s.repo.ChatRepo.GetMentions(userEmail, limit, offset)
+}
+
+func (s *chatService) checkParticipant(roomID uint, userEmail string) error {
+	ok, err := s.repo.ChatRepo.IsRoomParticipant(roomID, userEmail)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotParticipant
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"project/chat-service/model"
+	"project/chat-service/repository"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var ErrInvalidExportFormat = errors.New("export format must be jsonl, html or text")
+
+// exportBatchSize is how many messages are loaded at once while exporting
+const exportBatchSize = 500
+
+const exportTimeLayout = "2006-01-02 15:04:05 MST"
+
+type ExportService interface {
+	// ExportRoom writes every message of the room, oldest first, as a transcript
+	// in format. Only participants may export a room.
+	ExportRoom(ctx context.Context, roomID uint, userEmail, format string, w io.Writer) error
+}
+
+type exportService struct {
+	repo repository.Repository
+	log  *zap.Logger
+}
+
+func NewExportService(repo repository.Repository, log *zap.Logger) ExportService {
+	return &exportService{repo: repo, log: log}
+}
+
+// transcript renders an export in one format
+type transcript interface {
+	header(room *model.Room, participants []string, exportedAt time.Time) error
+	message(m model.ExportMessage) error
+	footer() error
+}
+
+func (s *exportService) ExportRoom(ctx context.Context, roomID uint, userEmail, format string, w io.Writer) error {
+	var out transcript
+	switch format {
+	case model.ExportFormatJSONL:
+		out = &jsonlTranscript{enc: json.NewEncoder(w)}
+	case model.ExportFormatHTML:
+		out = &htmlTranscript{w: w}
+	case model.ExportFormatText:
+		out = &textTranscript{w: w}
+	default:
+		return ErrInvalidExportFormat
+	}
+
+	ok, err := s.repo.ChatRepo.IsRoomParticipant(roomID, userEmail)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotParticipant
+	}
+
+	room, err := s.repo.ChatRepo.GetRoomByID(roomID)
+	if err != nil {
+		return err
+	}
+	participants, err := s.repo.ChatRepo.GetRoomParticipants(roomID)
+	if err != nil {
+		return err
+	}
+	emails := make([]string, len(participants))
+	for i, p := range participants {
+		emails[i] = p.UserEmail
+	}
+	sort.Strings(emails)
+
+	if err := out.header(room, emails, time.Now()); err != nil {
+		return err
+	}
+
+	var cursor *model.MessageCursor
+	exported := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		messages, err := s.repo.ChatRepo.GetRoomMessagesAfter(roomID, cursor, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			if err := out.message(model.ToExportMessage(m)); err != nil {
+				return err
+			}
+		}
+		exported += len(messages)
+		if len(messages) < exportBatchSize {
+			break
+		}
+		cursor = model.CursorOf(messages[len(messages)-1])
+	}
+
+	s.log.Info("exported room",
+		zap.Uint("roomId", roomID),
+		zap.String("userEmail", userEmail),
+		zap.String("format", format),
+		zap.Int("messages", exported),
+	)
+	return out.footer()
+}
+
+// jsonlTranscript writes the room, then one message per line
+type jsonlTranscript struct {
+	enc *json.Encoder
+}
+
+func (t *jsonlTranscript) header(room *model.Room, participants []string, exportedAt time.Time) error {
+	return t.enc.Encode(model.ExportRoomHeader{
+		Type:         "room",
+		RoomId:       room.ID,
+		Name:         room.Name,
+		Participants: participants,
+		ExportedAt:   exportedAt,
+	})
+}
+
+func (t *jsonlTranscript) message(m model.ExportMessage) error {
+	return t.enc.Encode(m)
+}
+
+func (t *jsonlTranscript) footer() error {
+	return nil
+}
+
+// textTranscript writes a log with one entry per message, continuation lines
+// are indented
+type textTranscript struct {
+	w io.Writer
+}
+
+func (t *textTranscript) header(room *model.Room, participants []string, exportedAt time.Time) error {
+	_, err := fmt.Fprintf(t.w, "Room: %s (#%d)\nParticipants: %s\nExported at: %s\n\n",
+		room.Name, room.ID, strings.Join(participants, ", "), exportedAt.UTC().Format(exportTimeLayout))
+	return err
+}
+
+func (t *textTranscript) message(m model.ExportMessage) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] #%d %s:", m.CreatedAt.UTC().Format(exportTimeLayout), m.Id, m.Sender)
+	switch {
+	case m.Deleted:
+		b.WriteString(" (deleted)")
+	case m.Content != "":
+		b.WriteString(" " + strings.ReplaceAll(m.Content, "\n", "\n    "))
+	}
+	if m.EditedAt != nil && !m.Deleted {
+		b.WriteString(" (edited)")
+	}
+	b.WriteString("\n")
+	if m.ReplyTo != nil {
+		fmt.Fprintf(&b, "    in reply to #%d\n", *m.ReplyTo)
+	}
+	for _, url := range m.AttachmentUrls {
+		fmt.Fprintf(&b, "    attachment: %s\n", url)
+	}
+
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+func (t *textTranscript) footer() error {
+	return nil
+}
+
+// htmlTranscript writes a single page with its styles inline. Replies link to
+// the anchor of the message they answer.
+type htmlTranscript struct {
+	w io.Writer
+}
+
+var htmlTranscriptTemplates = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"timestamp": func(t time.Time) string { return t.UTC().Format(exportTimeLayout) },
+}).Parse(`{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Room.Name}} transcript</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; color: #222; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1rem; }
+.message { padding: .5rem 0; border-bottom: 1px solid #f0f0f0; }
+.message:target { background: #fff8d6; }
+.meta { font-size: .85rem; color: #666; }
+.sender { font-weight: bold; color: #222; }
+.content { white-space: pre-wrap; margin: .25rem 0; }
+.deleted .content { font-style: italic; color: #999; }
+.reply, .attachment { display: block; font-size: .85rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Room.Name}}</h1>
+<p>Room #{{.Room.ID}}, exported {{timestamp .ExportedAt}}</p>
+<p>Participants: {{range $i, $p := .Participants}}{{if $i}}, {{end}}{{$p}}{{end}}</p>
+</header>
+<main>
+{{end}}{{define "message"}}<div class="message{{if .Deleted}} deleted{{end}}" id="m{{.Id}}">
+<div class="meta"><span class="sender">{{.Sender}}</span> <a href="#m{{.Id}}">{{timestamp .CreatedAt}}</a>{{if and .EditedAt (not .Deleted)}} (edited){{end}}</div>
+{{if .ReplyTo}}<a class="reply" href="#m{{.ReplyTo}}">In reply to #{{.ReplyTo}}</a>
+{{end}}{{if .Deleted}}<p class="content">This message was deleted</p>
+{{else}}<p class="content">{{.Content}}</p>
+{{end}}{{range .AttachmentUrls}}<a class="attachment" href="{{.}}">{{.}}</a>
+{{end}}</div>
+{{end}}{{define "footer"}}</main>
+</body>
+</html>
+{{end}}`))
+
+func (t *htmlTranscript) header(room *model.Room, participants []string, exportedAt time.Time) error {
+	return htmlTranscriptTemplates.ExecuteTemplate(t.w, "header", struct {
+		Room         *model.Room
+		Participants []string
+		ExportedAt   time.Time
+	}{room, participants, exportedAt})
+}
+
+func (t *htmlTranscript) message(m model.ExportMessage) error {
+	return htmlTranscriptTemplates.ExecuteTemplate(t.w, "message", m)
+}
+
+func (t *htmlTranscript) footer() error {
+	return htmlTranscriptTemplates.ExecuteTemplate(t.w, "footer", nil)
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"project/chat-service/helper"
+	"project/chat-service/model"
+	"project/chat-service/repository"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidImportSource = errors.New("import source must be whatsapp or telegram")
+	ErrInvalidTimeZone     = errors.New("unknown time zone")
+	ErrInvalidExport       = errors.New("invalid export file")
+	ErrEmptyImport         = errors.New("export file has no messages to import")
+)
+
+const defaultImportRoomName = "Imported chat"
+
+// importInviteTTL is how long the users mapped to senders have to join
+const importInviteTTL = 7 * 24 * time.Hour
+
+type ImportService interface {
+	// ImportChat creates a room from another messenger's export, owned by the
+	// importer. Messages keep the display name of their author whatever senders
+	// maps it to, the mapped users get an invite to join instead. Timestamps
+	// without a zone are read in timeZone, UTC when empty.
+	ImportChat(userEmail, source, roomName string, data []byte, senders map[string]string, timeZone string) (*model.ImportResult, error)
+}
+
+type importService struct {
+	repo repository.Repository
+	log  *zap.Logger
+}
+
+func NewImportService(repo repository.Repository, log *zap.Logger) ImportService {
+	return &importService{repo: repo, log: log}
+}
+
+func (s *importService) ImportChat(userEmail, source, roomName string, data []byte, senders map[string]string, timeZone string) (*model.ImportResult, error) {
+	if source != model.ImportSourceWhatsApp && source != model.ImportSourceTelegram {
+		return nil, ErrInvalidImportSource
+	}
+	loc := time.UTC
+	if timeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			return nil, ErrInvalidTimeZone
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	chatImport, err := s.repo.ImportRepo.GetImport(userEmail, checksum)
+	switch {
+	case err == nil && chatImport.Status == model.ImportStatusCompleted:
+		return &model.ImportResult{Import: chatImport, AlreadyImported: true}, nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		chatImport = &model.ChatImport{
+			UserEmail: userEmail,
+			Checksum:  checksum,
+			Source:    source,
+			Status:    model.ImportStatusPending,
+		}
+		if err := s.repo.ImportRepo.CreateImport(chatImport); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	result, err := s.importFile(chatImport, roomName, data, senders, loc)
+	if err != nil {
+		if updateErr := s.repo.ImportRepo.UpdateImportError(chatImport.ID, err.Error()); updateErr != nil {
+			s.log.Error("failed to record import error", zap.Uint("importId", chatImport.ID), zap.Error(updateErr))
+		}
+		return nil, err
+	}
+
+	s.log.Info("imported chat",
+		zap.Uint("importId", chatImport.ID),
+		zap.Uint("roomId", *chatImport.RoomID),
+		zap.String("source", chatImport.Source),
+		zap.Int("imported", chatImport.Imported),
+		zap.Int("failed", chatImport.Failed),
+	)
+	return result, nil
+}
+
+// importFile parses the export and stores it in a new room in one transaction
+func (s *importService) importFile(chatImport *model.ChatImport, roomName string, data []byte, senders map[string]string, loc *time.Location) (*model.ImportResult, error) {
+	var parsed helper.ParsedChat
+	switch chatImport.Source {
+	case model.ImportSourceWhatsApp:
+		parsed = helper.ParseWhatsApp(data, loc)
+	case model.ImportSourceTelegram:
+		var err error
+		if parsed, err = helper.ParseTelegram(data, loc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+	}
+
+	result := &model.ImportResult{Import: chatImport}
+	for _, e := range parsed.Errors {
+		result.Errors = append(result.Errors, model.ImportLineError{Line: e.Line, Reason: e.Reason})
+	}
+
+	// display names are matched regardless of case
+	emails := make(map[string]string, len(senders))
+	for name, email := range senders {
+		emails[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(email)
+	}
+
+	sort.SliceStable(parsed.Messages, func(i, j int) bool {
+		return parsed.Messages[i].SentAt.Before(parsed.Messages[j].SentAt)
+	})
+
+	skipped := parsed.Skipped
+	invited := make(map[string]bool)
+	unmapped := make(map[string]bool)
+	messages := make([]model.Message, 0, len(parsed.Messages))
+	positions := make(map[string]int, len(parsed.Messages))
+	replies := make(map[int]int)
+	for _, m := range parsed.Messages {
+		if m.Content == "" {
+			skipped++
+			continue
+		}
+
+		message := model.Message{
+			Type:        model.MessageTypeText,
+			SenderEmail: model.ImportSender(m.Sender),
+			SenderType:  model.SenderTypeImport,
+			Content:     m.Content,
+		}
+		message.CreatedAt, message.UpdatedAt = m.SentAt, m.SentAt
+		if email := emails[strings.ToLower(m.Sender)]; email == "" {
+			unmapped[m.Sender] = true
+		} else if email != chatImport.UserEmail {
+			invited[email] = true
+		}
+
+		if m.ReplyTo != "" {
+			if parent, ok := positions[m.ReplyTo]; ok {
+				replies[len(messages)] = parent
+			}
+		}
+		if m.SourceID != "" {
+			positions[m.SourceID] = len(messages)
+		}
+		messages = append(messages, message)
+	}
+	if len(messages) == 0 {
+		return nil, ErrEmptyImport
+	}
+
+	for name := range unmapped {
+		result.UnmappedSenders = append(result.UnmappedSenders, name)
+	}
+	sort.Strings(result.UnmappedSenders)
+
+	// only the importer joins, the others decide for themselves
+	roomParticipants := []model.RoomParticipant{{UserEmail: chatImport.UserEmail, Role: model.RoleOwner}}
+	invites := make([]model.RoomInvite, 0, len(invited))
+	expiresAt := time.Now().Add(importInviteTTL)
+	for email := range invited {
+		token, err := newToken()
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, model.RoomInvite{
+			TokenHash: hashToken(token),
+			Role:      model.RoleMember,
+			MaxUses:   1,
+			ExpiresAt: &expiresAt,
+			CreatedBy: chatImport.UserEmail,
+			Email:     email,
+		})
+		result.Invites = append(result.Invites, model.ImportInvite{Email: email, Token: token})
+	}
+
+	name := strings.TrimSpace(roomName)
+	if name == "" {
+		name = parsed.Title
+	}
+	if name == "" {
+		name = defaultImportRoomName
+	}
+
+	chatImport.Imported = len(messages)
+	chatImport.Skipped = skipped
+	chatImport.Failed = len(result.Errors)
+	room := &model.Room{Name: name}
+	if err := s.repo.ImportRepo.ImportRoom(chatImport, room, roomParticipants, invites, messages, replies); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"project/chat-service/model"
+	"project/chat-service/repository"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidHookToken = errors.New("invalid incoming webhook token")
+	ErrInvalidHookName  = errors.New("incoming webhook name must be 1 to 64 characters")
+	ErrEmptyHookMessage = errors.New("incoming webhook message has no text")
+)
+
+const maxHookNameLength = 64
+
+type IncomingWebhookService interface {
+	// CreateIncomingWebhook returns the webhook with the token its URL is made of,
+	// which is not stored and cannot be shown again
+	CreateIncomingWebhook(roomID uint, userEmail, name string) (*model.IncomingWebhook, string, error)
+	ListIncomingWebhooks(roomID uint, userEmail string) ([]model.IncomingWebhook, error)
+	// RotateIncomingWebhook replaces the token, the previous URL stops working
+	RotateIncomingWebhook(roomID, hookID uint, userEmail string) (*model.IncomingWebhook, string, error)
+	RevokeIncomingWebhook(roomID, hookID uint, userEmail string) error
+	// PostIncomingWebhook saves and publishes a message in the room of the webhook
+	PostIncomingWebhook(token string, message model.HookMessage) (*model.Message, error)
+}
+
+type incomingWebhookService struct {
+	repo      repository.Repository
+	chat      ChatService
+	publisher Publisher
+	log       *zap.Logger
+}
+
+func NewIncomingWebhookService(repo repository.Repository, chat ChatService, publisher Publisher, log *zap.Logger) IncomingWebhookService {
+	return &incomingWebhookService{repo: repo, chat: chat, publisher: publisher, log: log}
+}
+
+func (s *incomingWebhookService) CreateIncomingWebhook(roomID uint, userEmail, name string) (*model.IncomingWebhook, string, error) {
+	name, err := hookName(name)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, "", err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+	hook := &model.IncomingWebhook{
+		RoomID:    roomID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		CreatedBy: userEmail,
+	}
+	if err := s.repo.WebhookRepo.CreateIncomingWebhook(hook); err != nil {
+		return nil, "", err
+	}
+	return hook, token, nil
+}
+
+func (s *incomingWebhookService) ListIncomingWebhooks(roomID uint, userEmail string) ([]model.IncomingWebhook, error) {
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+	return s.repo.WebhookRepo.GetIncomingWebhooks(roomID)
+}
+
+func (s *incomingWebhookService) RotateIncomingWebhook(roomID, hookID uint, userEmail string) (*model.IncomingWebhook, string, error) {
+	hook, err := s.roomHook(roomID, hookID, userEmail)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+	hook.TokenHash = hashToken(token)
+	if err := s.repo.WebhookRepo.UpdateIncomingWebhookToken(hook.ID, hook.TokenHash); err != nil {
+		return nil, "", err
+	}
+	return hook, token, nil
+}
+
+func (s *incomingWebhookService) RevokeIncomingWebhook(roomID, hookID uint, userEmail string) error {
+	hook, err := s.roomHook(roomID, hookID, userEmail)
+	if err != nil {
+		return err
+	}
+	return s.repo.WebhookRepo.DeleteIncomingWebhook(hook.ID)
+}
+
+func (s *incomingWebhookService) PostIncomingWebhook(token string, message model.HookMessage) (*model.Message, error) {
+	if token == "" {
+		return nil, ErrInvalidHookToken
+	}
+	hook, err := s.repo.WebhookRepo.GetIncomingWebhookByTokenHash(hashToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidHookToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content := message.Content()
+	if content == "" {
+		return nil, ErrEmptyHookMessage
+	}
+	// a username that is not a valid name falls back to the webhook's own
+	sender, err := hookName(message.Username)
+	if err != nil {
+		sender = hook.Name
+	}
+
+	saved := &model.Message{
+		RoomID:      hook.RoomID,
+		SenderEmail: model.HookSender(sender),
+		SenderType:  model.SenderTypeHook,
+		Content:     content,
+	}
+	if err := s.chat.SaveMessage(saved); err != nil {
+		return nil, err
+	}
+	if err := s.publisher.PublishMessage(saved); err != nil {
+		s.log.Error("failed to publish incoming webhook message", zap.Uint("hookId", hook.ID), zap.Error(err))
+	}
+	return saved, nil
+}
+
+// roomHook loads an incoming webhook of the room for one of its admins
+func (s *incomingWebhookService) roomHook(roomID, hookID uint, userEmail string) (*model.IncomingWebhook, error) {
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+	hook, err := s.repo.WebhookRepo.GetIncomingWebhookByID(hookID)
+	if err != nil {
+		return nil, err
+	}
+	if hook.RoomID != roomID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return hook, nil
+}
+
+func hookName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxHookNameLength {
+		return "", ErrInvalidHookName
+	}
+	return name, nil
+}
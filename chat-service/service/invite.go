@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"project/chat-service/model"
+	"project/chat-service/repository"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidInvite       = errors.New("invite link is invalid or was revoked")
+	ErrInviteExpired       = errors.New("invite link has expired")
+	ErrInviteUsedUp        = errors.New("invite link has no uses left")
+	ErrInvalidInviteUses   = errors.New("invite max uses must not be negative")
+	ErrInvalidInviteExpiry = errors.New("invite expiry must be in the future")
+	ErrAlreadyParticipant  = repository.ErrAlreadyParticipant
+)
+
+type InviteService interface {
+	// CreateInvite returns the invite with the token its link is made of, which
+	// is not stored and cannot be shown again. maxUses 0 and a nil expiresAt
+	// leave the invite unlimited. Only the owner may invite admins.
+	CreateInvite(roomID uint, userEmail, role string, maxUses int, expiresAt *time.Time) (*model.RoomInvite, string, error)
+	ListInvites(roomID uint, userEmail string) ([]model.RoomInvite, error)
+	RevokeInvite(roomID, inviteID uint, userEmail string) error
+	// AcceptInvite adds userEmail to the room of the invite with its role
+	AcceptInvite(token, userEmail string) (*model.RoomParticipant, error)
+}
+
+type inviteService struct {
+	repo repository.Repository
+	chat ChatService
+	log  *zap.Logger
+}
+
+func NewInviteService(repo repository.Repository, chat ChatService, log *zap.Logger) InviteService {
+	return &inviteService{repo: repo, chat: chat, log: log}
+}
+
+func (s *inviteService) CreateInvite(roomID uint, userEmail, role string, maxUses int, expiresAt *time.Time) (*model.RoomInvite, string, error) {
+	if role == "" {
+		role = model.RoleMember
+	}
+	if role != model.RoleAdmin && role != model.RoleMember {
+		return nil, "", ErrInvalidRole
+	}
+	if maxUses < 0 {
+		return nil, "", ErrInvalidInviteUses
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidInviteExpiry
+	}
+	caller, err := roomAdmin(s.repo, roomID, userEmail)
+	if err != nil {
+		return nil, "", err
+	}
+	// an admin invite promotes whoever accepts it, which only the owner may do
+	if role == model.RoleAdmin && caller.Role != model.RoleOwner {
+		return nil, "", ErrNotRoomOwner
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+	invite := &model.RoomInvite{
+		RoomID:    roomID,
+		TokenHash: hashToken(token),
+		Role:      role,
+		MaxUses:   maxUses,
+		ExpiresAt: expiresAt,
+		CreatedBy: userEmail,
+	}
+	if err := s.repo.InviteRepo.CreateInvite(invite); err != nil {
+		return nil, "", err
+	}
+	return invite, token, nil
+}
+
+func (s *inviteService) ListInvites(roomID uint, userEmail string) ([]model.RoomInvite, error) {
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+	return s.repo.InviteRepo.GetInvites(roomID)
+}
+
+func (s *inviteService) RevokeInvite(roomID, inviteID uint, userEmail string) error {
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return err
+	}
+	invite, err := s.repo.InviteRepo.GetInviteByID(inviteID)
+	if err != nil {
+		return err
+	}
+	if invite.RoomID != roomID {
+		return gorm.ErrRecordNotFound
+	}
+	return s.repo.InviteRepo.DeleteInvite(invite.ID)
+}
+
+func (s *inviteService) AcceptInvite(token, userEmail string) (*model.RoomParticipant, error) {
+	if token == "" {
+		return nil, ErrInvalidInvite
+	}
+	invite, err := s.repo.InviteRepo.GetInviteByTokenHash(hashToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidInvite
+	}
+	if err != nil {
+		return nil, err
+	}
+	if invite.Email != "" && invite.Email != userEmail {
+		return nil, ErrInvalidInvite
+	}
+
+	ok, err := s.repo.ChatRepo.IsRoomParticipant(invite.RoomID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return nil, ErrAlreadyParticipant
+	}
+
+	// the use is taken before joining, so a full invite turns the last
+	// concurrent accepts away instead of letting them all in
+	now := time.Now()
+	consumed, err := s.repo.InviteRepo.ConsumeInvite(invite.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		if invite.Expired(now) {
+			return nil, ErrInviteExpired
+		}
+		return nil, ErrInviteUsedUp
+	}
+
+	participant := &model.RoomParticipant{
+		RoomID:    invite.RoomID,
+		UserEmail: userEmail,
+		Role:      invite.Role,
+	}
+	// a concurrent accept by the same user may have joined them in between, the
+	// unique index turns this one away with ErrAlreadyParticipant
+	if err := s.chat.CreateRoomParticipant(participant); err != nil {
+		if releaseErr := s.repo.InviteRepo.ReleaseInvite(invite.ID); releaseErr != nil {
+			s.log.Error("failed to release invite use", zap.Uint("inviteId", invite.ID), zap.Error(releaseErr))
+		}
+		return nil, err
+	}
+
+	joined := model.SystemPayload{Event: model.SystemEventMemberJoined, Target: userEmail}
+	if _, err := s.chat.PostSystemMessage(invite.RoomID, joined); err != nil {
+		s.log.Error("failed to announce invited participant", zap.Uint("roomId", invite.RoomID), zap.Error(err))
+	}
+	return participant, nil
+}
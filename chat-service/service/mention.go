@@ -0,0 +1,85 @@
+package service
+
+import (
+	"project/chat-service/model"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// mentionPattern matches @email and @handle tokens that are not part of a word
+// or of an email address
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([\w.+-]+@[\w-]+(?:\.[\w-]+)+|\w+(?:\.\w+)*)`)
+
+// mentionToken is a parsed @ token, offsets are in bytes
+type mentionToken struct {
+	start int
+	end   int
+	name  string
+}
+
+func parseMentions(content string) []mentionToken {
+	var tokens []mentionToken
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		tokens = append(tokens, mentionToken{
+			start: match[2] - 1, // include the @
+			end:   match[3],
+			name:  content[match[2]:match[3]],
+		})
+	}
+	return tokens
+}
+
+// resolveMentions finds the participants of the room referenced in content.
+// Tokens naming anybody else are plain text.
+func (s *chatService) resolveMentions(roomID uint, content string) ([]model.Mention, error) {
+	tokens := parseMentions(content)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	participants, err := s.repo.ChatRepo.GetRoomParticipants(roomID)
+	if err != nil {
+		return nil, err
+	}
+	emails := make(map[string]string, len(participants))
+	for _, p := range participants {
+		emails[strings.ToLower(p.UserEmail)] = p.UserEmail
+	}
+
+	var handles []string
+	for _, t := range tokens {
+		if !strings.Contains(t.name, "@") {
+			handles = append(handles, t.name)
+		}
+	}
+	byHandle := make(map[string]string)
+	if len(handles) > 0 {
+		users, err := s.repo.ChatRepo.GetUsersByUsernames(handles)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			byHandle[strings.ToLower(u.Username)] = strings.ToLower(u.Email)
+		}
+	}
+
+	var mentions []model.Mention
+	for _, t := range tokens {
+		key := strings.ToLower(t.name)
+		if !strings.Contains(t.name, "@") {
+			key = byHandle[key]
+		}
+		email, ok := emails[key]
+		if !ok {
+			continue
+		}
+		mentions = append(mentions, model.Mention{
+			RoomID:    roomID,
+			UserEmail: email,
+			Offset:    utf8.RuneCountInString(content[:t.start]),
+			Length:    utf8.RuneCountInString(content[t.start:t.end]),
+		})
+	}
+	return mentions, nil
+}
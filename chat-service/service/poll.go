@@ -0,0 +1,210 @@
+package service
+
+import (
+	"errors"
+	"project/chat-service/model"
+	"project/chat-service/realtime"
+	"project/chat-service/repository"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPoll     = errors.New("a poll needs a question and between 2 and 10 distinct options")
+	ErrInvalidPollVote = errors.New("vote for one option of the poll, or several when it is multiple choice")
+	ErrPollClosed      = repository.ErrPollClosed
+	ErrNotPollCreator  = errors.New("only the creator can close this poll")
+)
+
+const (
+	minPollOptions = 2
+	maxPollOptions = 10
+)
+
+type PollService interface {
+	CreatePoll(roomID uint, userEmail, question string, options []string, multipleChoice, anonymous bool) (*model.Poll, error)
+	Vote(roomID, pollID uint, userEmail string, optionIDs []uint) (*model.Poll, error)
+	RetractVote(roomID, pollID uint, userEmail string) (*model.Poll, error)
+	ClosePoll(roomID, pollID uint, userEmail string) (*model.Poll, error)
+}
+
+type pollService struct {
+	repo      repository.Repository
+	chat      ChatService
+	publisher Publisher
+	log       *zap.Logger
+}
+
+func NewPollService(repo repository.Repository, chat ChatService, publisher Publisher, log *zap.Logger) PollService {
+	return &pollService{repo: repo, chat: chat, publisher: publisher, log: log}
+}
+
+// CreatePoll posts a message carrying the poll to the room
+func (s *pollService) CreatePoll(roomID uint, userEmail, question string, options []string, multipleChoice, anonymous bool) (*model.Poll, error) {
+	question = strings.TrimSpace(question)
+	if question == "" || len(options) < minPollOptions || len(options) > maxPollOptions {
+		return nil, ErrInvalidPoll
+	}
+	seen := make(map[string]bool, len(options))
+	pollOptions := make([]model.PollOption, len(options))
+	for i, option := range options {
+		text := strings.TrimSpace(option)
+		key := strings.ToLower(text)
+		if text == "" || seen[key] {
+			return nil, ErrInvalidPoll
+		}
+		seen[key] = true
+		pollOptions[i] = model.PollOption{Text: text, Position: i}
+	}
+
+	ok, err := s.repo.ChatRepo.IsRoomParticipant(roomID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotParticipant
+	}
+
+	message := &model.Message{
+		RoomID:      roomID,
+		SenderEmail: userEmail,
+		Type:        model.MessageTypePoll,
+		Content:     question,
+		Poll: &model.Poll{
+			RoomID:         roomID,
+			Question:       question,
+			MultipleChoice: multipleChoice,
+			Anonymous:      anonymous,
+			CreatedBy:      userEmail,
+			Options:        pollOptions,
+		},
+	}
+	if err := s.chat.SaveMessage(message); err != nil {
+		return nil, err
+	}
+
+	if err := s.publisher.PublishMessage(message); err != nil {
+		s.log.Error("failed to publish poll", zap.Uint("roomId", roomID), zap.Error(err))
+	}
+	return message.Poll, nil
+}
+
+// Vote replaces the votes of userEmail with optionIDs
+func (s *pollService) Vote(roomID, pollID uint, userEmail string, optionIDs []uint) (*model.Poll, error) {
+	poll, participant, err := s.openPoll(roomID, pollID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make(map[uint]bool, len(poll.Options))
+	for _, o := range poll.Options {
+		valid[o.ID] = true
+	}
+	var choices []uint
+	chosen := make(map[uint]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if !valid[id] {
+			return nil, ErrInvalidPollVote
+		}
+		if !chosen[id] {
+			chosen[id] = true
+			choices = append(choices, id)
+		}
+	}
+	if len(choices) == 0 || (!poll.MultipleChoice && len(choices) > 1) {
+		return nil, ErrInvalidPollVote
+	}
+
+	if err := s.repo.PollRepo.ReplaceVotes(poll.ID, participant, choices); err != nil {
+		return nil, err
+	}
+	return s.publishTally(poll.ID)
+}
+
+// RetractVote removes every vote of userEmail
+func (s *pollService) RetractVote(roomID, pollID uint, userEmail string) (*model.Poll, error) {
+	poll, participant, err := s.openPoll(roomID, pollID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	retracted, err := s.repo.PollRepo.DeleteVotes(poll.ID, participant.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !retracted {
+		return poll, nil
+	}
+	return s.publishTally(poll.ID)
+}
+
+// ClosePoll stops the poll from accepting votes, which only its creator may do
+func (s *pollService) ClosePoll(roomID, pollID uint, userEmail string) (*model.Poll, error) {
+	poll, _, err := s.openPoll(roomID, pollID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	if poll.CreatedBy != userEmail {
+		return nil, ErrNotPollCreator
+	}
+
+	closed, err := s.repo.PollRepo.ClosePoll(poll.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		return nil, ErrPollClosed
+	}
+	return s.publishTally(poll.ID)
+}
+
+// openPoll loads a poll of the room still accepting votes, and the participant
+// userEmail is in that room
+func (s *pollService) openPoll(roomID, pollID uint, userEmail string) (*model.Poll, *model.RoomParticipant, error) {
+	poll, err := s.repo.PollRepo.GetPollByID(pollID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if poll.RoomID != roomID {
+		return nil, nil, ErrMessageNotInRoom
+	}
+
+	// the poll goes away with its message
+	if _, err := s.repo.ChatRepo.GetMessageByID(poll.MessageID); err != nil {
+		return nil, nil, err
+	}
+
+	participant, err := s.repo.ChatRepo.GetRoomParticipant(roomID, userEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrNotParticipant
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if poll.ClosedAt != nil {
+		return nil, nil, ErrPollClosed
+	}
+	return poll, participant, nil
+}
+
+// publishTally pushes the current tally of a poll to its room
+func (s *pollService) publishTally(pollID uint) (*model.Poll, error) {
+	poll, err := s.repo.PollRepo.GetPollByID(pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := realtime.Event{
+		Type:   realtime.EventPollUpdated,
+		RoomId: poll.RoomID,
+		Data:   poll.Tally(),
+	}
+	if err := s.publisher.PublishEvent(event); err != nil {
+		s.log.Error("failed to publish poll tally", zap.Uint("pollId", poll.ID), zap.Error(err))
+	}
+	return poll, nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"project/chat-service/config"
+	"project/chat-service/database"
+	"project/chat-service/helper"
+	"project/chat-service/model"
+	"project/chat-service/realtime"
+	"project/chat-service/repository"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxPreviewsPerMessage bounds the URLs unfurled for one message
+	maxPreviewsPerMessage = 3
+	// maxConcurrentUnfurls bounds the messages unfurled at once, others go
+	// without preview
+	maxConcurrentUnfurls = 16
+)
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// PreviewService fetches the metadata of the links posted in messages
+type PreviewService interface {
+	// Unfurl previews the links of a saved message in the background
+	Unfurl(message *model.Message)
+}
+
+// LinkFetcher reads the metadata of a page
+type LinkFetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*helper.LinkMetadata, error)
+}
+
+type previewService struct {
+	repo      repository.Repository
+	rdb       *database.Cacher
+	fetcher   LinkFetcher
+	publisher Publisher
+	timeout   time.Duration
+	slots     chan struct{}
+	log       *zap.Logger
+}
+
+func NewPreviewService(repo repository.Repository, rdb *database.Cacher, fetcher LinkFetcher, publisher Publisher, cfg config.Config, log *zap.Logger) PreviewService {
+	return &previewService{
+		repo:      repo,
+		rdb:       rdb,
+		fetcher:   fetcher,
+		publisher: publisher,
+		timeout:   time.Duration(cfg.LinkPreviewTimeout) * time.Second,
+		slots:     make(chan struct{}, maxConcurrentUnfurls),
+		log:       log,
+	}
+}
+
+func (s *previewService) Unfurl(message *model.Message) {
+	urls := extractURLs(message.Content)
+	if len(urls) == 0 {
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.log.Warn("too many link previews in flight, skipping", zap.Uint("messageId", message.ID))
+		return
+	}
+	go func() {
+		defer func() { <-s.slots }()
+		s.unfurl(message.ID, message.RoomID, urls)
+	}()
+}
+
+func (s *previewService) unfurl(messageID, roomID uint, urls []string) {
+	var previews []model.LinkPreview
+	for _, u := range urls {
+		metadata := s.metadata(u)
+		if metadata == nil || metadata.Empty() {
+			continue
+		}
+		previews = append(previews, model.LinkPreview{
+			MessageID:   messageID,
+			URL:         u,
+			Title:       metadata.Title,
+			Description: metadata.Description,
+			ImageURL:    metadata.ImageURL,
+			SiteName:    metadata.SiteName,
+		})
+	}
+	if len(previews) == 0 {
+		return
+	}
+
+	if err := s.repo.ChatRepo.SaveLinkPreviews(previews); err != nil {
+		s.log.Error("failed to save link previews", zap.Uint("messageId", messageID), zap.Error(err))
+		return
+	}
+
+	event := realtime.Event{
+		Type:   realtime.EventLinkPreview,
+		RoomId: roomID,
+		Data:   model.LinkPreviewEvent{MessageId: messageID, Previews: model.ToSocketLinkPreviews(previews)},
+	}
+	if err := s.publisher.PublishEvent(event); err != nil {
+		s.log.Error("failed to publish link previews", zap.Uint("messageId", messageID), zap.Error(err))
+	}
+}
+
+// metadata returns the metadata of a URL, from the cache when another message
+// linked it recently. Failures are cached as well so a dead link is not
+// fetched over and over.
+func (s *previewService) metadata(u string) *helper.LinkMetadata {
+	key := previewCacheKey(u)
+	if cached, err := s.rdb.Get(key); err == nil {
+		var metadata helper.LinkMetadata
+		if err := json.Unmarshal([]byte(cached), &metadata); err == nil {
+			return &metadata
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	metadata, err := s.fetcher.Fetch(ctx, u)
+	if err != nil {
+		s.log.Debug("failed to fetch link preview", zap.String("url", u), zap.Error(err))
+		metadata = &helper.LinkMetadata{URL: u}
+	}
+
+	if data, err := json.Marshal(metadata); err == nil {
+		if err := s.rdb.Set(key, string(data)); err != nil {
+			s.log.Error("failed to cache link preview", zap.String("url", u), zap.Error(err))
+		}
+	}
+	return metadata
+}
+
+func previewCacheKey(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return "link_preview:" + hex.EncodeToString(sum[:])
+}
+
+// extractURLs returns the distinct http(s) URLs of a content, in order, without
+// the punctuation closing the sentence around them
+func extractURLs(content string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, u := range urlPattern.FindAllString(content, -1) {
+		u = strings.TrimRight(u, ".,;:!?)]}")
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+		if len(urls) == maxPreviewsPerMessage {
+			break
+		}
+	}
+	return urls
+}
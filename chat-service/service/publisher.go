@@ -0,0 +1,60 @@
+package service
+
+import (
+	"encoding/json"
+	"project/chat-service/database"
+	"project/chat-service/model"
+	"project/chat-service/realtime"
+)
+
+// Publisher pushes realtime updates to the websockets held by the api-gateway
+type Publisher interface {
+	// PublishMessage sends a new message to its room and notifies the users it mentions
+	PublishMessage(message *model.Message) error
+	// PublishEvent sends an event through the channel of its room
+	PublishEvent(event realtime.Event) error
+}
+
+type redisPublisher struct {
+	rdb *database.Cacher
+}
+
+func NewPublisher(rdb *database.Cacher) Publisher {
+	return &redisPublisher{rdb: rdb}
+}
+
+func (p *redisPublisher) PublishMessage(message *model.Message) error {
+	socketMessage := model.ToSocketMessage(message)
+	if err := p.publish(realtime.RoomChannel(message.RoomID), socketMessage); err != nil {
+		return err
+	}
+
+	mentioned := make([]string, len(message.Mentions))
+	for i, mention := range message.Mentions {
+		mentioned[i] = mention.UserEmail
+	}
+	for _, recipient := range realtime.MentionRecipients(message.SenderEmail, mentioned) {
+		event := realtime.Event{
+			Type:      realtime.EventMention,
+			RoomId:    message.RoomID,
+			Recipient: recipient,
+			Data:      socketMessage,
+		}
+		if err := p.publish(realtime.UserChannel(recipient), event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *redisPublisher) PublishEvent(event realtime.Event) error {
+	return p.publish(realtime.RoomChannel(event.RoomId), event)
+}
+
+func (p *redisPublisher) publish(channel string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return p.rdb.Publish(channel, string(data))
+}
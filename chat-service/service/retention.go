@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"project/chat-service/config"
+	"project/chat-service/helper"
+	"project/chat-service/model"
+	"project/chat-service/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var ErrInvalidRetention = errors.New("retention must be one of off, 24h, 7d or 90d")
+
+// sweepBatchSize is how many expired messages are deleted per transaction
+const sweepBatchSize = 500
+
+type RetentionService interface {
+	SetRoomRetention(roomID uint, userEmail, retention string) (*model.Message, error)
+	SweepExpired(now time.Time) (int, error)
+}
+
+type retentionService struct {
+	repo repository.Repository
+	chat ChatService
+	cfg  config.Config
+	log  *zap.Logger
+}
+
+func NewRetentionService(repo repository.Repository, chat ChatService, cfg config.Config, log *zap.Logger) RetentionService {
+	return &retentionService{repo: repo, chat: chat, cfg: cfg, log: log}
+}
+
+// SetRoomRetention changes how long new messages of the room are kept and posts
+// a system message about it. It returns nil when the setting did not change.
+func (s *retentionService) SetRoomRetention(roomID uint, userEmail, retention string) (*model.Message, error) {
+	if !model.ValidRetention(retention) {
+		return nil, ErrInvalidRetention
+	}
+
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+
+	room, err := s.repo.ChatRepo.GetRoomByID(roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room.Retention == retention {
+		return nil, nil
+	}
+
+	if err := s.repo.ChatRepo.SetRoomRetention(roomID, retention); err != nil {
+		return nil, err
+	}
+
+	return s.chat.PostSystemMessage(roomID, model.SystemPayload{
+		Event: model.SystemEventRetentionChanged,
+		Actor: userEmail,
+		Value: retention,
+	})
+}
+
+// SweepExpired hard deletes every message expired at now, along with attachments
+// no other message uses, and returns how many messages were deleted
+func (s *retentionService) SweepExpired(now time.Time) (int, error) {
+	total := 0
+	for {
+		attachments, deleted, err := s.repo.ChatRepo.DeleteExpiredMessages(now, sweepBatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+
+		for _, url := range attachments {
+			s.deleteAttachment(url)
+		}
+
+		if deleted < sweepBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (s *retentionService) deleteAttachment(url string) {
+	if s.cfg.AttachmentDeleteURL == "" {
+		s.log.Warn("no attachment delete url configured, keeping expired attachment", zap.String("url", url))
+		return
+	}
+	if err := helper.DeleteFileThirdPartyAPI(s.cfg.AttachmentDeleteURL, url); err != nil {
+		s.log.Error("failed to delete expired attachment", zap.String("url", url), zap.Error(err))
+	}
+}
+
+// RunRetentionSweeper deletes expired messages every interval until ctx is done
+func RunRetentionSweeper(ctx context.Context, retention RetentionService, interval time.Duration, log *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := retention.SweepExpired(now)
+			if err != nil {
+				log.Error("failed to sweep expired messages", zap.Error(err))
+			}
+			if deleted > 0 {
+				log.Info("swept expired messages", zap.Int("deleted", deleted))
+			}
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"project/chat-service/model"
+	"project/chat-service/realtime"
+	"project/chat-service/repository"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotRoomAdmin    = errors.New("only room admins can do this")
+	ErrNotRoomOwner    = errors.New("only the room owner can do this")
+	ErrInvalidRole     = errors.New("role must be admin or member")
+	ErrOwnerRole       = errors.New("the role of the room owner cannot be changed")
+	ErrInvalidRoomName = errors.New("room name must be 1 to 100 characters")
+	ErrRemoveOwner     = errors.New("the room owner cannot be removed")
+	ErrOwnerMustLeave  = errors.New("the owner must transfer ownership before leaving")
+	ErrInvalidNewOwner = errors.New("ownership can only be transferred to another participant")
+)
+
+const maxRoomNameLength = 100
+
+// CheckRoomAdmin fails unless userEmail is an owner or admin of the room
+func (s *chatService) CheckRoomAdmin(roomID uint, userEmail string) error {
+	_, err := roomAdmin(s.repo, roomID, userEmail)
+	return err
+}
+
+// RenameRoom changes the name of the room and posts a system message about it
+func (s *chatService) RenameRoom(roomID uint, userEmail, name string) (*model.Message, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxRoomNameLength {
+		return nil, ErrInvalidRoomName
+	}
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ChatRepo.RenameRoom(roomID, name); err != nil {
+		return nil, err
+	}
+	return s.PostSystemMessage(roomID, model.SystemPayload{
+		Event: model.SystemEventRoomRenamed,
+		Actor: userEmail,
+		Value: name,
+	})
+}
+
+// SetParticipantRole makes a participant an admin or a plain member, which only
+// the owner may do
+func (s *chatService) SetParticipantRole(roomID uint, userEmail, targetEmail, role string) (*model.RoomParticipant, error) {
+	if role != model.RoleAdmin && role != model.RoleMember {
+		return nil, ErrInvalidRole
+	}
+	caller, err := s.repo.ChatRepo.GetRoomParticipant(roomID, userEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotParticipant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if caller.Role != model.RoleOwner {
+		return nil, ErrNotRoomOwner
+	}
+
+	target, err := s.repo.ChatRepo.GetRoomParticipant(roomID, targetEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotParticipant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target.Role == model.RoleOwner {
+		return nil, ErrOwnerRole
+	}
+	if target.Role == role {
+		return target, nil
+	}
+
+	if err := s.repo.ChatRepo.SetParticipantRole(target.ID, role); err != nil {
+		return nil, err
+	}
+	target.Role = role
+	if _, err := s.PostSystemMessage(roomID, model.SystemPayload{
+		Event:  model.SystemEventRoleChanged,
+		Actor:  userEmail,
+		Target: targetEmail,
+		Value:  role,
+	}); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// RemoveParticipant removes targetEmail from the room. Admins remove members,
+// only the owner removes admins and nobody removes the owner.
+func (s *chatService) RemoveParticipant(roomID uint, userEmail, targetEmail string) (*model.Message, error) {
+	if targetEmail == userEmail {
+		return s.LeaveRoom(roomID, userEmail)
+	}
+	caller, err := roomAdmin(s.repo, roomID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.repo.ChatRepo.GetRoomParticipant(roomID, targetEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotParticipant
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case target.Role == model.RoleOwner:
+		return nil, ErrRemoveOwner
+	case target.Role == model.RoleAdmin && caller.Role != model.RoleOwner:
+		return nil, ErrNotRoomOwner
+	}
+
+	return s.removeParticipant(target, model.SystemPayload{
+		Event:  model.SystemEventMemberRemoved,
+		Actor:  userEmail,
+		Target: targetEmail,
+	})
+}
+
+// LeaveRoom removes userEmail from the room. The owner can only leave a room
+// nobody else is in, otherwise ownership must be transferred first.
+func (s *chatService) LeaveRoom(roomID uint, userEmail string) (*model.Message, error) {
+	participant, err := s.repo.ChatRepo.GetRoomParticipant(roomID, userEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotParticipant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if participant.Role == model.RoleOwner {
+		participants, err := s.repo.ChatRepo.GetRoomParticipants(roomID)
+		if err != nil {
+			return nil, err
+		}
+		if len(participants) > 1 {
+			return nil, ErrOwnerMustLeave
+		}
+	}
+
+	return s.removeParticipant(participant, model.SystemPayload{
+		Event:  model.SystemEventMemberLeft,
+		Target: userEmail,
+	})
+}
+
+// TransferOwnership makes targetEmail the owner of the room, the previous
+// owner stays on as an admin
+func (s *chatService) TransferOwnership(roomID uint, userEmail, targetEmail string) (*model.Message, error) {
+	owner, err := s.repo.ChatRepo.GetRoomParticipant(roomID, userEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotParticipant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if owner.Role != model.RoleOwner {
+		return nil, ErrNotRoomOwner
+	}
+	if targetEmail == userEmail {
+		return nil, ErrInvalidNewOwner
+	}
+	target, err := s.repo.ChatRepo.GetRoomParticipant(roomID, targetEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidNewOwner
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ChatRepo.TransferOwnership(owner, target); err != nil {
+		return nil, err
+	}
+	return s.PostSystemMessage(roomID, model.SystemPayload{
+		Event:  model.SystemEventOwnerChanged,
+		Actor:  userEmail,
+		Target: targetEmail,
+	})
+}
+
+// removeParticipant deletes the participant, announces it to the room and has
+// the gateway close the participant's websockets on the room
+func (s *chatService) removeParticipant(participant *model.RoomParticipant, event model.SystemPayload) (*model.Message, error) {
+	if err := s.repo.ChatRepo.DeleteRoomParticipant(participant.ID); err != nil {
+		return nil, err
+	}
+
+	message, err := s.PostSystemMessage(participant.RoomID, event)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := realtime.Event{
+		Type:      realtime.EventParticipantRemoved,
+		RoomId:    participant.RoomID,
+		Recipient: participant.UserEmail,
+		Data:      event,
+	}
+	if err := s.publisher.PublishEvent(removed); err != nil {
+		s.log.Error("failed to publish participant removal", zap.Uint("roomId", participant.RoomID), zap.String("userEmail", participant.UserEmail), zap.Error(err))
+	}
+	return message, nil
+}
+
+// roomAdmin returns the participant of userEmail when they are an owner or
+// admin of the room
+func roomAdmin(repo repository.Repository, roomID uint, userEmail string) (*model.RoomParticipant, error) {
+	participant, err := repo.ChatRepo.GetRoomParticipant(roomID, userEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotParticipant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !participant.IsAdmin() {
+		return nil, ErrNotRoomAdmin
+	}
+	return participant, nil
+}
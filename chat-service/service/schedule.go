@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"project/chat-service/model"
+	"project/chat-service/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSendAtNotInFuture   = errors.New("send time must be in the future")
+	ErrScheduledNotPending = errors.New("scheduled message was already sent or canceled")
+)
+
+// dispatchBatchSize is how many due messages one replica claims at a time
+const dispatchBatchSize = 50
+
+// sendingTimeout is how long a claimed message may stay sending before the
+// replica that claimed it is assumed to have died
+const sendingTimeout = 5 * time.Minute
+
+// errSendInterrupted is recorded on messages left sending by a dead replica
+var errSendInterrupted = errors.New("sending was interrupted")
+
+type ScheduleService interface {
+	ScheduleMessage(scheduled *model.ScheduledMessage) error
+	ListScheduled(userEmail string, roomID uint) ([]model.ScheduledMessage, error)
+	CancelScheduled(id uint, userEmail string) (*model.ScheduledMessage, error)
+	DispatchDue(now time.Time) (int, error)
+}
+
+type scheduleService struct {
+	repo      repository.Repository
+	chat      ChatService
+	publisher Publisher
+	log       *zap.Logger
+}
+
+func NewScheduleService(repo repository.Repository, chat ChatService, publisher Publisher, log *zap.Logger) ScheduleService {
+	return &scheduleService{repo: repo, chat: chat, publisher: publisher, log: log}
+}
+
+func (s *scheduleService) ScheduleMessage(scheduled *model.ScheduledMessage) error {
+	if !scheduled.SendAt.After(time.Now()) {
+		return ErrSendAtNotInFuture
+	}
+
+	ok, err := s.repo.ChatRepo.IsRoomParticipant(scheduled.RoomID, scheduled.SenderEmail)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotParticipant
+	}
+
+	scheduled.Status = model.ScheduledStatusPending
+	return s.repo.ScheduleRepo.CreateScheduledMessage(scheduled)
+}
+
+func (s *scheduleService) ListScheduled(userEmail string, roomID uint) ([]model.ScheduledMessage, error) {
+	return s.repo.ScheduleRepo.GetScheduledMessages(userEmail, roomID)
+}
+
+// CancelScheduled cancels a pending message, which only its sender may do
+func (s *scheduleService) CancelScheduled(id uint, userEmail string) (*model.ScheduledMessage, error) {
+	scheduled, err := s.repo.ScheduleRepo.GetScheduledMessageByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduled.SenderEmail != userEmail {
+		return nil, ErrNotMessageSender
+	}
+
+	canceled, err := s.repo.ScheduleRepo.CancelScheduledMessage(id)
+	if err != nil {
+		return nil, err
+	}
+	if !canceled {
+		return nil, ErrScheduledNotPending
+	}
+
+	scheduled.Status = model.ScheduledStatusCanceled
+	return scheduled, nil
+}
+
+// DispatchDue sends the messages due at now and returns how many were sent.
+// A message whose send fails is marked failed and never retried, so a message
+// is sent at most once even with several replicas dispatching. For the same
+// reason a message stuck sending past sendingTimeout is marked failed rather
+// than claimed again, it may have been saved before its replica died.
+func (s *scheduleService) DispatchDue(now time.Time) (int, error) {
+	stale, err := s.repo.ScheduleRepo.FailStaleScheduledMessages(now.Add(-sendingTimeout), errSendInterrupted)
+	if err != nil {
+		return 0, err
+	}
+	if stale > 0 {
+		s.log.Warn("failed scheduled messages stuck sending", zap.Int64("count", stale))
+	}
+
+	sent := 0
+	for {
+		claimed, err := s.repo.ScheduleRepo.ClaimDueScheduledMessages(now, dispatchBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, scheduled := range claimed {
+			message, err := s.send(scheduled)
+			var messageID *uint
+			if err == nil {
+				messageID = &message.ID
+				sent++
+			} else {
+				s.log.Error("failed to send scheduled message", zap.Uint("scheduledId", scheduled.ID), zap.Error(err))
+			}
+
+			if err := s.repo.ScheduleRepo.FinishScheduledMessage(scheduled.ID, messageID, err); err != nil {
+				s.log.Error("failed to finish scheduled message", zap.Uint("scheduledId", scheduled.ID), zap.Error(err))
+			}
+		}
+
+		if len(claimed) < dispatchBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// send saves a scheduled message like any other and publishes it to its room
+func (s *scheduleService) send(scheduled model.ScheduledMessage) (*model.Message, error) {
+	ok, err := s.repo.ChatRepo.IsRoomParticipant(scheduled.RoomID, scheduled.SenderEmail)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotParticipant
+	}
+
+	message := &model.Message{
+		RoomID:        scheduled.RoomID,
+		SenderEmail:   scheduled.SenderEmail,
+		Content:       scheduled.Content,
+		AttachmentURL: scheduled.AttachmentURL,
+		ReplyTo:       scheduled.ReplyTo,
+	}
+	if err := s.chat.SaveMessage(message); err != nil {
+		return nil, err
+	}
+
+	// the message is stored, failing to publish it only delays it until the next fetch
+	if err := s.publisher.PublishMessage(message); err != nil {
+		s.log.Error("failed to publish scheduled message", zap.Uint("messageId", message.ID), zap.Error(err))
+	}
+	return message, nil
+}
+
+// RunScheduleDispatcher dispatches due scheduled messages every interval until
+// ctx is done
+func RunScheduleDispatcher(ctx context.Context, schedules ScheduleService, interval time.Duration, log *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sent, err := schedules.DispatchDue(now)
+			if err != nil {
+				log.Error("failed to dispatch scheduled messages", zap.Error(err))
+			}
+			if sent > 0 {
+				log.Info("dispatched scheduled messages", zap.Int("sent", sent))
+			}
+		}
+	}
+}
@@ -1,17 +1,46 @@
 package service
 
 import (
+	"project/chat-service/config"
+	"project/chat-service/database"
+	"project/chat-service/helper"
 	"project/chat-service/repository"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type Service struct {
-	ChatService ChatService
+	ChatService            ChatService
+	ScheduleService        ScheduleService
+	RetentionService       RetentionService
+	PollService            PollService
+	BotService             BotService
+	WebhookService         WebhookService
+	IncomingWebhookService IncomingWebhookService
+	ExportService          ExportService
+	ImportService          ImportService
+	InviteService          InviteService
 }
 
-func NewService(repo repository.Repository, log *zap.Logger) Service {
+func NewService(repo repository.Repository, cfg config.Config, rdb *database.Cacher, log *zap.Logger) Service {
+	publisher := NewPublisher(rdb)
+	fetcher := helper.NewLinkFetcher(time.Duration(cfg.LinkPreviewTimeout)*time.Second, cfg.LinkPreviewMaxBytes, false)
+	previews := NewPreviewService(repo, rdb, fetcher, publisher, cfg, log)
+	webhooks := NewWebhookService(repo, rdb, cfg, log)
+	chat := NewChatService(repo, cfg, publisher, previews, webhooks, log)
+	schedule := NewScheduleService(repo, chat, publisher, log)
+	polls := NewPollService(repo, chat, publisher, log)
 	return Service{
-		ChatService: NewChatService(repo),
+		ChatService:            chat,
+		ScheduleService:        schedule,
+		RetentionService:       NewRetentionService(repo, chat, cfg, log),
+		PollService:            polls,
+		BotService:             NewBotService(repo, chat, polls, schedule, publisher, rdb, log),
+		WebhookService:         webhooks,
+		IncomingWebhookService: NewIncomingWebhookService(repo, chat, publisher, log),
+		ExportService:          NewExportService(repo, log),
+		ImportService:          NewImportService(repo, log),
+		InviteService:          NewInviteService(repo, chat, log),
 	}
 }
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"project/chat-service/config"
+	"project/chat-service/database"
+	"project/chat-service/helper"
+	"project/chat-service/model"
+	"project/chat-service/repository"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http or https url")
+
+const (
+	// webhookQueue is the redis list of deliveries waiting for an attempt
+	webhookQueue = "webhook_deliveries"
+	// maxWebhookAttempts is how many times a delivery is tried, the waits in
+	// between double from webhookRetryDelay
+	maxWebhookAttempts = 6
+	webhookRetryDelay  = 10 * time.Second
+	webhookTimeout     = 10 * time.Second
+	// notifyBacklog is how many events wait for their deliveries to be created
+	// before Notify blocks
+	notifyBacklog = 256
+
+	WebhookSignatureHeader = "X-Chat-Signature"
+	WebhookTimestampHeader = "X-Chat-Timestamp"
+	WebhookEventHeader     = "X-Chat-Event"
+	WebhookDeliveryHeader  = "X-Chat-Delivery"
+)
+
+type WebhookService interface {
+	CreateWebhook(roomID uint, userEmail, rawURL string) (*model.RoomWebhook, error)
+	ListWebhooks(roomID uint, userEmail string) ([]model.RoomWebhook, error)
+	DeleteWebhook(roomID, webhookID uint, userEmail string) error
+	ListDeliveries(roomID, webhookID uint, userEmail string, limit int, page int) (*model.DeliveryPage, error)
+	// Notify queues event for every webhook of the message's room. The
+	// deliveries are created in the background, in the order of the events.
+	Notify(event string, message *model.Message)
+	// DeliverDue attempts the queued deliveries that are due and returns how
+	// many were attempted
+	DeliverDue(now time.Time) (int, error)
+}
+
+// webhookJob is a queued attempt of a delivery
+type webhookJob struct {
+	DeliveryID uint      `json:"deliveryId"`
+	NotBefore  time.Time `json:"notBefore"`
+}
+
+// webhookEvent is an event waiting for its deliveries to be created
+type webhookEvent struct {
+	event   string
+	roomID  uint
+	payload string
+}
+
+type webhookService struct {
+	repo   repository.Repository
+	rdb    *database.Cacher
+	client *http.Client
+	events chan webhookEvent
+	log    *zap.Logger
+}
+
+func NewWebhookService(repo repository.Repository, rdb *database.Cacher, cfg config.Config, log *zap.Logger) WebhookService {
+	client := helper.NewPublicHTTPClient(webhookTimeout, cfg.WebhookAllowPrivate)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	s := &webhookService{repo: repo, rdb: rdb, client: client, events: make(chan webhookEvent, notifyBacklog), log: log}
+	go func() {
+		for e := range s.events {
+			s.createDeliveries(e)
+		}
+	}()
+	return s
+}
+
+// CreateWebhook registers a webhook on the room and returns it with the secret
+// its payloads are signed with
+func (s *webhookService) CreateWebhook(roomID uint, userEmail, rawURL string) (*model.RoomWebhook, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" || target.User != nil {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+
+	secret, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	webhook := &model.RoomWebhook{
+		RoomID:    roomID,
+		URL:       target.String(),
+		Secret:    secret,
+		CreatedBy: userEmail,
+	}
+	if err := s.repo.WebhookRepo.CreateWebhook(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) ListWebhooks(roomID uint, userEmail string) ([]model.RoomWebhook, error) {
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+	return s.repo.WebhookRepo.GetWebhooks(roomID)
+}
+
+// DeleteWebhook removes a webhook, its queued deliveries are dropped
+func (s *webhookService) DeleteWebhook(roomID, webhookID uint, userEmail string) error {
+	webhook, err := s.roomWebhook(roomID, webhookID, userEmail)
+	if err != nil {
+		return err
+	}
+	return s.repo.WebhookRepo.DeleteWebhook(webhook.ID)
+}
+
+func (s *webhookService) ListDeliveries(roomID, webhookID uint, userEmail string, limit int, page int) (*model.DeliveryPage, error) {
+	webhook, err := s.roomWebhook(roomID, webhookID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	if page <= 0 {
+		page = 1
+	}
+	deliveries, total, err := s.repo.WebhookRepo.GetDeliveries(webhook.ID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / limit
+	if int(total)%limit != 0 {
+		totalPages++
+	}
+	return &model.DeliveryPage{
+		Deliveries: deliveries,
+		Page:       page,
+		Limit:      limit,
+		TotalItems: int(total),
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *webhookService) Notify(event string, message *model.Message) {
+	// the payload is taken now, the message may change before it is sent
+	socketMessage := model.ToSocketMessage(message)
+	if event == model.WebhookEventMessageDeleted {
+		socketMessage = model.SocketMessage{Id: message.ID, RoomId: message.RoomID, Sender: message.SenderEmail}
+	}
+	payload, err := json.Marshal(model.WebhookPayload{
+		Event:      event,
+		RoomId:     message.RoomID,
+		Message:    socketMessage,
+		OccurredAt: time.Now(),
+	})
+	if err != nil {
+		s.log.Error("failed to encode webhook payload", zap.Error(err))
+		return
+	}
+
+	e := webhookEvent{event: event, roomID: message.RoomID, payload: string(payload)}
+	select {
+	case s.events <- e:
+	default:
+		// waiting keeps the events in order
+		s.log.Warn("webhook events backlog is full", zap.Uint("roomId", message.RoomID))
+		s.events <- e
+	}
+}
+
+// createDeliveries records a delivery of the event for every webhook of its room
+// and queues their first attempt
+func (s *webhookService) createDeliveries(e webhookEvent) {
+	webhooks, err := s.repo.WebhookRepo.GetWebhooks(e.roomID)
+	if err != nil {
+		s.log.Error("failed to load room webhooks", zap.Uint("roomId", e.roomID), zap.Error(err))
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	deliveries := make([]model.WebhookDelivery, len(webhooks))
+	for i, w := range webhooks {
+		deliveries[i] = model.WebhookDelivery{
+			WebhookID: w.ID,
+			Event:     e.event,
+			Payload:   e.payload,
+			Status:    model.DeliveryStatusPending,
+		}
+	}
+	if err := s.repo.WebhookRepo.CreateDeliveries(deliveries); err != nil {
+		s.log.Error("failed to create webhook deliveries", zap.Uint("roomId", e.roomID), zap.Error(err))
+		return
+	}
+	for _, d := range deliveries {
+		s.enqueue(webhookJob{DeliveryID: d.ID})
+	}
+}
+
+func (s *webhookService) DeliverDue(now time.Time) (int, error) {
+	attempted := 0
+	// jobs that are not due go back to the end of the queue, look at each once
+	for queued := s.rdb.GetLength(webhookQueue); queued > 0; queued-- {
+		raw, err := s.rdb.Pop(webhookQueue)
+		if err != nil {
+			// emptied by another replica
+			break
+		}
+
+		var job webhookJob
+		if err := json.Unmarshal([]byte(raw), &job); err != nil {
+			s.log.Error("dropping malformed webhook job", zap.String("job", raw), zap.Error(err))
+			continue
+		}
+		if job.NotBefore.After(now) {
+			s.enqueue(job)
+			continue
+		}
+
+		if err := s.attempt(job.DeliveryID, now); err != nil {
+			// the job is already off the queue, put it back so the delivery is
+			// not left pending forever
+			s.enqueue(webhookJob{DeliveryID: job.DeliveryID, NotBefore: now.Add(webhookRetryDelay)})
+			return attempted, err
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// attempt posts a delivery once and schedules the next attempt when it fails
+func (s *webhookService) attempt(deliveryID uint, now time.Time) error {
+	delivery, err := s.repo.WebhookRepo.GetDelivery(deliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status != model.DeliveryStatusPending {
+		return nil
+	}
+
+	delivery.Attempts++
+	webhook, err := s.repo.WebhookRepo.GetWebhookByID(delivery.WebhookID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.LastError = "webhook was removed"
+		delivery.NextAttemptAt = nil
+		return s.repo.WebhookRepo.UpdateDelivery(delivery)
+	case err != nil:
+		return err
+	}
+
+	statusCode, postErr := s.post(webhook, delivery, now)
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+	if postErr == nil {
+		delivery.Status = model.DeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		return s.repo.WebhookRepo.UpdateDelivery(delivery)
+	}
+
+	delivery.LastError = postErr.Error()
+	if delivery.Attempts >= maxWebhookAttempts {
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+		return s.repo.WebhookRepo.UpdateDelivery(delivery)
+	}
+
+	next := now.Add(webhookRetryDelay << (delivery.Attempts - 1))
+	delivery.NextAttemptAt = &next
+	if err := s.repo.WebhookRepo.UpdateDelivery(delivery); err != nil {
+		return err
+	}
+	s.enqueue(webhookJob{DeliveryID: delivery.ID, NotBefore: next})
+	return nil
+}
+
+// post sends the payload signed with the webhook secret. The signature is the
+// hex HMAC-SHA256 of "<timestamp>.<body>", so receivers can reject replays.
+func (s *webhookService) post(webhook *model.RoomWebhook, delivery *model.WebhookDelivery, now time.Time) (int, error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write([]byte(timestamp + "." + delivery.Payload))
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("webhook answered %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func (s *webhookService) enqueue(job webhookJob) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+	if err := s.rdb.Push(webhookQueue, data); err != nil {
+		s.log.Error("failed to queue webhook delivery", zap.Uint("deliveryId", job.DeliveryID), zap.Error(err))
+	}
+}
+
+// roomWebhook loads a webhook of the room for one of its admins
+func (s *webhookService) roomWebhook(roomID, webhookID uint, userEmail string) (*model.RoomWebhook, error) {
+	if _, err := roomAdmin(s.repo, roomID, userEmail); err != nil {
+		return nil, err
+	}
+	webhook, err := s.repo.WebhookRepo.GetWebhookByID(webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.RoomID != roomID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return webhook, nil
+}
+
+func RunWebhookDispatcher(ctx context.Context, webhooks WebhookService, interval time.Duration, log *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			attempted, err := webhooks.DeliverDue(now)
+			if err != nil {
+				log.Error("failed to deliver webhooks", zap.Error(err))
+			}
+			if attempted > 0 {
+				log.Info("attempted webhook deliveries", zap.Int("attempted", attempted))
+			}
+		}
+	}
+}